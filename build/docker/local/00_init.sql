@@ -11,5 +11,42 @@ CREATE TABLE IF NOT EXISTS tb_video_jobs (
     status VARCHAR(50) NOT NULL,
     video_path VARCHAR(255),
     output_path VARCHAR(255),
+    frame_count INTEGER,
+    archive_size_bytes BIGINT,
+    download_url TEXT,
+    download_url_expires_at TIMESTAMPTZ,
+    failure_code VARCHAR(50),
+    failure_stage VARCHAR(50),
+    failure_message TEXT,
+    video_metadata JSONB,
+    lease_owner VARCHAR(255),
+    lease_expires_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ DEFAULT now()
 );
+
+CREATE TABLE IF NOT EXISTS tb_video_job_status_history (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id uuid NOT NULL REFERENCES tb_video_jobs(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL,
+    reason TEXT,
+    worker_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_job_id ON tb_video_job_status_history (job_id, created_at);
+
+CREATE TABLE IF NOT EXISTS tb_job_outbox (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id uuid NOT NULL REFERENCES tb_video_jobs(id) ON DELETE CASCADE,
+    destination VARCHAR(50) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    headers JSONB,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_outbox_due ON tb_job_outbox (available_at) WHERE sent_at IS NULL;
@@ -2,61 +2,168 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input/httpserver"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/metrics"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/notification"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/processor"
-	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/queue"
-	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/repository"
-	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
-	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/aws"
-	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/postgres"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/workspace"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/otel"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/config"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/service"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/driver"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 func main() {
-	log.Println("INFO: Starting the worker service...")
-
 	// Load configuration
 	config.Init()
 	cfg := config.Vars
 
-	ctx := context.Background()
+	// Every log line, including those of libraries using the log package,
+	// is written as JSON and tagged with the worker ID.
+	slog.SetDefault(logger.New(os.Stdout, cfg.LogLevel).With(logger.WorkerIDKey, cfg.WorkerID))
+	slog.Info("Starting the worker service...")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// Initialize clients
-	db, err := postgres.NewPostgresClient()
+	tracerProvider, err := otel.NewTracerProvider(ctx)
 	if err != nil {
-		log.Fatalf("FATAL: erro ao conectar ao banco: %v", err)
+		fatal("Failed to initialize tracing.", err)
 	}
 
-	awsCfg, err := aws.NewAWSConfig(ctx)
+	// Initialize adapters
+	drv, err := driver.New(ctx, cfg.Driver)
 	if err != nil {
-		log.Fatalf("FATAL ERROR: Failed to initialize AWS configuration: %v", err)
+		fatal("Failed to initialize the adapters.", err)
+	}
+	defer drv.Close()
+	if drv.Failed != nil {
+		go func() {
+			fatal("An adapter stopped working.", <-drv.Failed)
+		}()
 	}
 
-	s3Client := s3.NewFromConfig(awsCfg, func(o *s3.Options) { o.UsePathStyle = true })
-	sqsClient := sqs.NewFromConfig(awsCfg)
-
-	// Initialize adapters
-	videoRepository := repository.NewVideoJobRepository(db)
-	storageAdapter := storage.NewS3Adapter(s3Client, cfg.S3Bucket)
 	videoProcessingAdapter := processor.NewFFmpegProcessor()
-	sqsMessageQueueAdapter := queue.NewSQSAdapter(sqsClient, cfg.SQSErrorQueueURL, cfg.SQSWorkQueueURL)
+	workspaceManager := workspace.NewManager(cfg.WorkspaceRoot, cfg.WorkerID)
+
+	readiness := drv.Readiness
+	readiness["ffmpeg"] = videoProcessingAdapter.Ping
+
+	var notifier ports.Notifier = notification.NewNoopNotifier()
+	if cfg.SMTPHost != "" {
+		notifier = notification.NewSMTPNotifier(notification.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+	} else {
+		slog.Info("SMTP_HOST is not set. Users won't be notified by email.")
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	metricsRecorder := metrics.NewPrometheusRecorder(registry)
+
+	// Clean up workspaces left behind by crashed runs before taking new jobs
+	swept, err := workspaceManager.Sweep()
+	if err != nil {
+		fatal("Failed to sweep orphaned workspaces.", err)
+	}
+	if swept > 0 {
+		slog.Info("Removed orphaned workspaces.", "count", swept, "root", cfg.WorkspaceRoot, "worker_id", cfg.WorkerID)
+	}
 
 	// Initialize service and consumer
 	jobService := service.NewJobService(
-		videoRepository,
-		storageAdapter,
+		drv.Repository,
+		drv.Storage,
 		videoProcessingAdapter,
-		sqsMessageQueueAdapter,
+		drv.Events,
+		notifier,
+		workspaceManager,
+		metricsRecorder,
+		service.Config{
+			MaxAttempts:   cfg.MaxJobAttempts,
+			WorkerID:      cfg.WorkerID,
+			LeaseDuration: cfg.JobLeaseDuration,
+			Limits: service.VideoLimits{
+				MaxDuration: cfg.MaxVideoDuration,
+				MaxSize:     cfg.MaxVideoSize,
+				MaxWidth:    cfg.MaxVideoWidth,
+				MaxHeight:   cfg.MaxVideoHeight,
+			},
+		},
 	)
 
-	sqsConsumer := input.NewConsumer(sqsMessageQueueAdapter, jobService)
+	jobConsumer := input.NewConsumer(drv.Queue, jobService, metricsRecorder, input.ConsumerConfig{
+		Concurrency:       cfg.WorkerConcurrency,
+		VisibilityTimeout: cfg.SQSVisibilityTimeout,
+		HeartbeatInterval: cfg.SQSHeartbeatInterval,
+		RetryDelay:        cfg.SQSRetryDelay,
+		StallTimeout:      cfg.ConsumerStallTimeout,
+	})
+
+	leaseReaper := service.NewLeaseReaper(drv.Repository, cfg.WorkerID, cfg.LeaseReaperInterval)
+	go leaseReaper.Start(ctx)
+
+	outboxRelay := service.NewOutboxRelay(drv.Outbox, drv.Queue, drv.Events, service.OutboxRelayConfig{
+		Interval:   cfg.OutboxRelayInterval,
+		BatchSize:  cfg.OutboxBatchSize,
+		MaxBackoff: cfg.OutboxMaxBackoff,
+	})
+	go outboxRelay.Start(ctx)
+
+	readiness["consumer"] = jobConsumer.CheckReadiness
+	httpServer := httpserver.NewServer(cfg.HTTPAddr, registry, httpserver.Checks{
+		Liveness: map[string]httpserver.Check{
+			"consumer": jobConsumer.CheckLiveness,
+		},
+		Readiness: readiness,
+	})
+	go func() {
+		if err := httpServer.Start(); err != nil {
+			fatal("HTTP server failed.", err)
+		}
+	}()
+
+	// Start consumer and block until a shutdown signal is received
+	jobConsumer.Start(ctx)
+
+	slog.Info("Shutdown signal received. Waiting for in-flight jobs...", "timeout", cfg.ShutdownDrainTimeout.String())
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
+	defer cancel()
+	if err := jobConsumer.Shutdown(drainCtx); err != nil {
+		slog.Warn("In-flight jobs were cancelled and will be redelivered.", "error", err)
+	}
+
+	// The HTTP server stays up while draining so the last jobs are still
+	// scraped and /readyz reports the worker as not ready.
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelStop()
+	if err := httpServer.Shutdown(stopCtx); err != nil {
+		slog.Warn("Failed to stop HTTP server.", "error", err)
+	}
+	if err := tracerProvider.Shutdown(stopCtx); err != nil {
+		slog.Warn("Failed to flush pending traces.", "error", err)
+	}
+	slog.Info("Worker stopped.")
+}
 
-	// Start consumer
-	go sqsConsumer.Start(ctx)
-	select {}
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -3,75 +3,331 @@ package input
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input")
+
+const (
+	// maxReceiveBatch is the largest batch taken in a single receive, which is
+	// also the most SQS allows.
+	maxReceiveBatch = 10
+	// receiveWait is the long polling wait used on every receive.
+	receiveWait = 20 * time.Second
+)
+
+type ConsumerConfig struct {
+	// Concurrency is the maximum number of jobs processed at the same time.
+	Concurrency int
+	// VisibilityTimeout is the visibility applied to a message on every heartbeat.
+	VisibilityTimeout time.Duration
+	// HeartbeatInterval is how often the visibility of an in-flight message is
+	// extended. Zero disables the heartbeat.
+	HeartbeatInterval time.Duration
+	// RetryDelay is how long a message stays hidden after a retryable failure
+	// before the broker redelivers it.
+	RetryDelay time.Duration
+	// StallTimeout is how long the receive loop may go without making
+	// progress, while a worker is free, before the liveness check fails. Zero
+	// disables the check.
+	StallTimeout time.Duration
+}
+
 type Consumer struct {
-	queue     ports.SQSAdapter
-	processor ports.JobService
+	queue             ports.QueueAdapter
+	processor         ports.JobService
+	metrics           ports.MetricsRecorder
+	slots             chan struct{}
+	visibilityTimeout time.Duration
+	heartbeatInterval time.Duration
+	retryDelay        time.Duration
+	stallTimeout      time.Duration
+
+	// lastTick is when the receive loop last made progress, in Unix
+	// nanoseconds. waitingForSlot is set while the loop waits for a busy
+	// worker, which is the only time it may legitimately go quiet.
+	lastTick       atomic.Int64
+	waitingForSlot atomic.Bool
+	// draining is set once Start returns and in-flight jobs are finishing.
+	draining atomic.Bool
+
+	// jobCtx is the parent of every in-flight job. It is detached from the
+	// context given to Start so that stopping the receive loop does not abort
+	// running jobs; it is only cancelled when Shutdown gives up draining.
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+	inFlight   sync.WaitGroup
+}
+
+func NewConsumer(queue ports.QueueAdapter, processor ports.JobService, metrics ports.MetricsRecorder, cfg ConsumerConfig) *Consumer {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	c := &Consumer{
+		queue:             queue,
+		processor:         processor,
+		metrics:           metrics,
+		slots:             make(chan struct{}, max(cfg.Concurrency, 1)),
+		visibilityTimeout: cfg.VisibilityTimeout,
+		heartbeatInterval: cfg.HeartbeatInterval,
+		retryDelay:        cfg.RetryDelay,
+		stallTimeout:      cfg.StallTimeout,
+		jobCtx:            jobCtx,
+		cancelJobs:        cancelJobs,
+	}
+	c.tick()
+	return c
+}
+
+// CheckLiveness fails when the receive loop is stuck: it has not made
+// progress for longer than the stall timeout even though a worker is free.
+func (c *Consumer) CheckLiveness(_ context.Context) error {
+	if c.stallTimeout <= 0 || c.draining.Load() || c.waitingForSlot.Load() {
+		return nil
+	}
+	if idle := time.Since(time.Unix(0, c.lastTick.Load())); idle > c.stallTimeout {
+		return fmt.Errorf("receive loop made no progress for %s", idle.Round(time.Second))
+	}
+	return nil
 }
 
-func NewConsumer(queue ports.SQSAdapter, processor ports.JobService) *Consumer {
-	return &Consumer{
-		queue:     queue,
-		processor: processor,
+// CheckReadiness fails once the consumer stopped taking new jobs.
+func (c *Consumer) CheckReadiness(_ context.Context) error {
+	if c.draining.Load() {
+		return errors.New("consumer is draining in-flight jobs")
 	}
+	return nil
+}
+
+func (c *Consumer) tick() {
+	c.lastTick.Store(time.Now().UnixNano())
 }
 
 func (c *Consumer) Start(ctx context.Context) {
-	log.Println("INFO: SQS consumer started. Listening for jobs...")
+	log := logger.FromContext(ctx)
+	log.Info("Consumer started. Listening for jobs...", "workers", cap(c.slots))
+	// Jobs outlive ctx during a drain, but log through the same logger.
+	jobCtx := logger.WithContext(c.jobCtx, log)
+	defer c.draining.Store(true)
 	for {
-		select {
-		case <-ctx.Done():
-			log.Println("INFO: Consumer context cancelled. Exiting loop.")
+		c.tick()
+		free, ok := c.acquireSlots(ctx)
+		if !ok {
+			log.Info("Consumer context cancelled. Exiting loop.")
 			return
-		default:
-			msgs, err := c.queue.Receive(ctx, 10, 20)
-			if err != nil {
-				log.Printf("ERROR: Failed to receive messages: %v. Retrying in 10s...", err)
-				time.Sleep(10 * time.Second)
-				continue
+		}
+
+		receiveStarted := time.Now()
+		msgs, err := c.queue.Receive(ctx, free, receiveWait)
+		receiveEnded := time.Now()
+		c.tick()
+		if err != nil {
+			c.releaseSlots(free)
+			if ctx.Err() != nil {
+				log.Info("Consumer context cancelled. Exiting loop.")
+				return
 			}
-			for _, msg := range msgs {
-				go c.handleMessage(context.Background(), msg)
+			c.metrics.ReceiveFailed()
+			log.Error("Failed to receive messages. Retrying in 10s...", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Second):
 			}
+			continue
+		}
+
+		c.releaseSlots(free - len(msgs))
+		c.inFlight.Add(len(msgs))
+		for _, msg := range msgs {
+			c.metrics.JobReceived()
+			go func() {
+				defer c.inFlight.Done()
+				defer c.releaseSlots(1)
+				c.metrics.JobStarted()
+				defer c.metrics.JobFinished()
+				c.handleMessage(jobCtx, msg, receiveStarted, receiveEnded)
+			}()
 		}
 	}
 }
 
-func (c *Consumer) handleMessage(ctx context.Context, msg types.Message) {
-	if msg.Body == nil || msg.ReceiptHandle == nil {
-		log.Println("ERROR: Invalid message (nil body or receipt handle).")
-		return
+// Shutdown waits for in-flight jobs to finish. Start must have returned
+// before it is called. If ctx expires first, the remaining jobs are cancelled
+// and their messages are left on the queue so they are redelivered once the
+// visibility timeout elapses.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	drained := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		c.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		logger.FromContext(ctx).Warn("Drain timeout reached. Cancelling in-flight jobs...")
+		c.cancelJobs()
+		<-drained
+		return ctx.Err()
+	}
+}
+
+// acquireSlots blocks until at least one worker is free and then takes every
+// other free worker, up to the receive batch limit, without blocking again. The
+// returned count is how many messages may be received.
+func (c *Consumer) acquireSlots(ctx context.Context) (int, bool) {
+	c.waitingForSlot.Store(true)
+	select {
+	case <-ctx.Done():
+		c.waitingForSlot.Store(false)
+		return 0, false
+	case c.slots <- struct{}{}:
+		// Ticks first so the check never sees a stale tick in between.
+		c.tick()
+		c.waitingForSlot.Store(false)
+	}
+
+	acquired := 1
+	for acquired < maxReceiveBatch {
+		select {
+		case c.slots <- struct{}{}:
+			acquired++
+		default:
+			return acquired, true
+		}
+	}
+	return acquired, true
+}
+
+func (c *Consumer) releaseSlots(n int) {
+	for range n {
+		<-c.slots
 	}
+}
+
+func (c *Consumer) handleMessage(ctx context.Context, msg model.Message, receiveStarted, receiveEnded time.Time) {
+	// Continue the trace started by the upstream API, if it sent one. The
+	// message span starts with the receive call that delivered it.
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Attributes))
+	ctx, span := tracer.Start(ctx, "queue.process_message",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithTimestamp(receiveStarted),
+		trace.WithAttributes(
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingMessageID(msg.ID),
+		),
+	)
+	defer span.End()
+	_, receiveSpan := tracer.Start(ctx, "queue.receive",
+		trace.WithTimestamp(receiveStarted),
+		trace.WithAttributes(semconv.MessagingOperationTypeReceive),
+	)
+	receiveSpan.End(trace.WithTimestamp(receiveEnded))
+
+	attempt := msg.Attempt
+	ctx, log := logger.With(ctx,
+		logger.MessageIDKey, msg.ID,
+		logger.ReceiveCountKey, attempt,
+	)
 
 	var jobMsg model.JobMessageEvent
 
-	if err := json.Unmarshal([]byte(*msg.Body), &jobMsg); err != nil {
-		log.Printf("ERROR: Failed to decode message body. Deleting message. Error: %v", err)
-		_ = c.queue.Delete(ctx, *msg.ReceiptHandle)
+	if err := json.Unmarshal(msg.Body, &jobMsg); err != nil {
+		log.Error("Failed to decode message body. Deleting message.", "error", err)
+		_ = msg.Ack(ctx)
 		return
 	}
 
-	log.Printf("INFO: [Job %s] Processing started.", jobMsg.JobID)
-	err := c.processor.ProcessJob(ctx, jobMsg.JobID)
+	ctx, log = logger.With(ctx, logger.JobIDKey, jobMsg.JobID)
+	span.SetAttributes(attribute.String("job.id", jobMsg.JobID), attribute.Int("job.attempt", attempt))
+	log.Info("Processing started.")
+	stopHeartbeat := c.startHeartbeat(ctx, msg)
+	err := c.processor.ProcessJob(ctx, jobMsg, attempt)
+	stopHeartbeat()
 	if err != nil {
-		if delErr := c.queue.Delete(ctx, *msg.ReceiptHandle); delErr != nil {
-			log.Printf("ERROR: [Job %s] Failed to delete message after error: %v", jobMsg.JobID, delErr)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	cancelled := ctx.Err() != nil
+	// A job that already finished must still get its message deleted while
+	// the worker is shutting down.
+	ctx = context.WithoutCancel(ctx)
+	if err != nil {
+		if cancelled {
+			log.Warn("Job cancelled during shutdown. Message left for redelivery.")
+			return
+		}
+		if model.IsRetryable(err) {
+			c.scheduleRetry(ctx, msg, err)
+			return
+		}
+		if delErr := msg.Ack(ctx); delErr != nil {
+			log.Error("Failed to delete message after error.", "error", delErr)
 		} else {
-			log.Printf("INFO: [Job %s] ProcessJob failed. Message deleted", jobMsg.JobID)
+			log.Info("ProcessJob failed. Message deleted.")
 		}
 		return
 	}
 
-	if err := c.queue.Delete(ctx, *msg.ReceiptHandle); err != nil {
-		log.Printf("ERROR: [Job %s] Failed to delete message: %v", jobMsg.JobID, err)
+	if err := msg.Ack(ctx); err != nil {
+		log.Error("Failed to delete message.", "error", err)
 	} else {
-		log.Printf("INFO: [Job %s] Message processed and deleted.", jobMsg.JobID)
+		log.Info("Message processed and deleted.")
+	}
+}
+
+// scheduleRetry leaves the message on the queue and has it delivered again
+// after the configured retry delay.
+func (c *Consumer) scheduleRetry(ctx context.Context, msg model.Message, cause error) {
+	log := logger.FromContext(ctx)
+	log.Warn("ProcessJob failed with a retryable error. Message left for redelivery.", "delay_seconds", int(c.retryDelay/time.Second), "error", cause)
+	if err := msg.Nack(ctx, c.retryDelay); err != nil {
+		log.Error("Failed to schedule retry, message will be redelivered after its visibility timeout.", "error", err)
+	}
+}
+
+// startHeartbeat keeps extending the visibility of a message while its job is
+// running, so the broker does not hand it to another worker. The returned
+// function stops the heartbeat and waits for it to exit.
+func (c *Consumer) startHeartbeat(ctx context.Context, msg model.Message) func() {
+	if c.heartbeatInterval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := msg.Extend(ctx, c.visibilityTimeout); err != nil && ctx.Err() == nil {
+					logger.FromContext(ctx).Warn("Failed to extend message visibility.", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
 	}
 }
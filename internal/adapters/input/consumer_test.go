@@ -1,16 +1,23 @@
 package input_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
-	"github.com/samber/lo"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
 )
 
@@ -18,17 +25,35 @@ type consumerTestSuite struct {
 	suite.Suite
 
 	ctx         context.Context
-	mockQueue   *mocks.MockSQSAdapter
+	mockQueue   *mocks.MockQueueAdapter
+	mockAcker   *mocks.MockMessageAcker
 	mockService *mocks.MockJobService
+	mockMetrics *mocks.MockMetricsRecorder
 	consumer    *input.Consumer
 }
 
+// allowMetrics accepts any metric, for tests that don't check them.
+func allowMetrics(m *mocks.MockMetricsRecorder) {
+	m.EXPECT().JobReceived().AnyTimes()
+	m.EXPECT().JobStarted().AnyTimes()
+	m.EXPECT().JobFinished().AnyTimes()
+	m.EXPECT().JobSucceeded(gomock.Any()).AnyTimes()
+	m.EXPECT().JobFailed(gomock.Any(), gomock.Any()).AnyTimes()
+	m.EXPECT().ObserveStage(gomock.Any(), gomock.Any()).AnyTimes()
+	m.EXPECT().AddBytesDownloaded(gomock.Any()).AnyTimes()
+	m.EXPECT().AddBytesUploaded(gomock.Any()).AnyTimes()
+	m.EXPECT().ReceiveFailed().AnyTimes()
+}
+
 func (suite *consumerTestSuite) BeforeTest(_, _ string) {
 	ctrl := gomock.NewController(suite.T())
 	suite.ctx = context.Background()
-	suite.mockQueue = mocks.NewMockSQSAdapter(ctrl)
+	suite.mockQueue = mocks.NewMockQueueAdapter(ctrl)
+	suite.mockAcker = mocks.NewMockMessageAcker(ctrl)
 	suite.mockService = mocks.NewMockJobService(ctrl)
-	suite.consumer = input.NewConsumer(suite.mockQueue, suite.mockService)
+	suite.mockMetrics = mocks.NewMockMetricsRecorder(ctrl)
+	allowMetrics(suite.mockMetrics)
+	suite.consumer = input.NewConsumer(suite.mockQueue, suite.mockService, suite.mockMetrics, input.ConsumerConfig{Concurrency: 10})
 }
 
 func Test_ConsumerTestSuite(t *testing.T) {
@@ -41,42 +66,39 @@ func (suite *consumerTestSuite) Test_Start_Success() {
 	defer cancel()
 
 	mockMsg := struct {
-		jobID     string
-		body      string
-		receipt   string
+		jobID string
+		body  string
 	}{
-		jobID:     "job-123",
-		body:      `{"job_id":"job-123","video_path":"upload/video.mp4"}`,
-		receipt:   "receipt-handle-123",
+		jobID: "job-123",
+		body:  `{"job_id":"job-123","video_path":"upload/video.mp4"}`,
 	}
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{
 			{
-				Body:          &mockMsg.body,
-				ReceiptHandle: &mockMsg.receipt,
+				Body:    []byte(mockMsg.body),
+				Attempt: 1,
+				Acker:   suite.mockAcker,
 			},
 		}, nil).
 		Times(1)
 
-	
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{}, nil).
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
 		AnyTimes()
 
 	suite.mockService.EXPECT().
-		ProcessJob(gomock.Any(), mockMsg.jobID).
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: mockMsg.jobID}, 1).
 		Return(nil).
 		Times(1)
 
-	suite.mockQueue.EXPECT().
-		Delete(gomock.Any(), mockMsg.receipt).
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
 		Return(nil).
 		Times(1)
 
-	
 	go func() {
 		time.Sleep(100 * time.Millisecond)
 		cancel()
@@ -85,52 +107,64 @@ func (suite *consumerTestSuite) Test_Start_Success() {
 	suite.consumer.Start(ctx)
 }
 
-func (suite *consumerTestSuite) Test_Start_ReceiveError() {
+func (suite *consumerTestSuite) Test_Start_DecodesFrameOptions() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	
+	body := `{"job_id":"job-opts","options":{"interval_seconds":2,"format":"webp","quality":70,"max_height":480}}`
+
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return(nil, assert.AnError).
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{{Body: []byte(body), Attempt: 1, Acker: suite.mockAcker}}, nil).
 		Times(1)
 
-	
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{}, nil).
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
 		AnyTimes()
 
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{
+			JobID: "job-opts",
+			Options: &domain.FrameOptions{
+				IntervalSeconds: 2,
+				Format:          domain.ImageFormatWEBP,
+				Quality:         70,
+				MaxHeight:       480,
+			},
+		}, 1).
+		Return(nil).
+		Times(1)
+
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
+		Return(nil).
+		Times(1)
+
 	go func() {
-		time.Sleep(150 * time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
 		cancel()
 	}()
 
 	suite.consumer.Start(ctx)
 }
 
-func (suite *consumerTestSuite) Test_Start_InvalidMessage_NilBodyOrReceipt() {
+func (suite *consumerTestSuite) Test_Start_ReceiveError() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	msgs := []types.Message{
-		{Body: nil, ReceiptHandle: nil},
-		{Body: nil, ReceiptHandle: lo.ToPtr("receip")},
-		{Body: lo.ToPtr(`{"job_id":"job-1","video_path":"foo.mp4"}`), ReceiptHandle: nil},
-	}
-
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return(msgs, nil).
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return(nil, assert.AnError).
 		Times(1)
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{}, nil).
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
 		AnyTimes()
 
 	go func() {
-		time.Sleep(100 * time.Millisecond)
+		time.Sleep(150 * time.Millisecond)
 		cancel()
 	}()
 
@@ -142,23 +176,22 @@ func (suite *consumerTestSuite) Test_Start_InvalidJSONBody() {
 	defer cancel()
 
 	body := "not-a-json"
-	receipt := "receipt-err"
-	msgs := []types.Message{
-		{Body: &body, ReceiptHandle: &receipt},
+	msgs := []domain.Message{
+		{Body: []byte(body), Attempt: 1, Acker: suite.mockAcker},
 	}
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
+		Receive(gomock.Any(), 10, 20*time.Second).
 		Return(msgs, nil).
 		Times(1)
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{}, nil).
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
 		AnyTimes()
 
-	suite.mockQueue.EXPECT().
-		Delete(gomock.Any(), receipt).
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
 		Return(nil).
 		Times(1)
 
@@ -175,37 +208,36 @@ func (suite *consumerTestSuite) Test_Start_ProcessJobError() {
 	defer cancel()
 
 	mockMsg := struct {
-		jobID     string
-		body      string
-		receipt   string
+		jobID string
+		body  string
 	}{
-		jobID:     "job-err",
-		body:      `{"job_id":"job-err","video_path":"video-err.mp4"}`,
-		receipt:   "receipt-handle-err",
+		jobID: "job-err",
+		body:  `{"job_id":"job-err","video_path":"video-err.mp4"}`,
 	}
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{
 			{
-				Body:          &mockMsg.body,
-				ReceiptHandle: &mockMsg.receipt,
+				Body:    []byte(mockMsg.body),
+				Attempt: 1,
+				Acker:   suite.mockAcker,
 			},
 		}, nil).
 		Times(1)
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{}, nil).
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
 		AnyTimes()
 
 	suite.mockService.EXPECT().
-		ProcessJob(gomock.Any(), mockMsg.jobID).
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: mockMsg.jobID}, 1).
 		Return(assert.AnError).
 		Times(1)
 
-	suite.mockQueue.EXPECT().
-		Delete(gomock.Any(), mockMsg.receipt).
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
 		Return(nil).
 		Times(1)
 
@@ -217,42 +249,84 @@ func (suite *consumerTestSuite) Test_Start_ProcessJobError() {
 	suite.consumer.Start(ctx)
 }
 
+func (suite *consumerTestSuite) Test_Start_RetryableProcessJobError() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumer := input.NewConsumer(suite.mockQueue, suite.mockService, suite.mockMetrics, input.ConsumerConfig{
+		Concurrency: 10,
+		RetryDelay:  30 * time.Second,
+	})
+
+	body := `{"job_id":"job-retry"}`
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{
+			{Body: []byte(body), Attempt: 2, Acker: suite.mockAcker},
+		}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
+		AnyTimes()
+
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-retry"}, 2).
+		Return(domain.NewRetryableError(assert.AnError)).
+		Times(1)
+
+	// The message is hidden for the retry delay instead of being deleted.
+	suite.mockAcker.EXPECT().
+		Nack(gomock.Any(), 30*time.Second).
+		Return(nil).
+		Times(1)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	consumer.Start(ctx)
+	suite.NoError(consumer.Shutdown(context.Background()))
+}
+
 func (suite *consumerTestSuite) Test_Start_QueueDeleteError() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	mockMsg := struct {
-		jobID     string
-		body      string
-		receipt   string
+		jobID string
+		body  string
 	}{
-		jobID:     "job-del-err",
-		body:      `{"job_id":"job-del-err","video_path":"video-del-err.mp4"}`,
-		receipt:   "receipt-handle-del-err",
+		jobID: "job-del-err",
+		body:  `{"job_id":"job-del-err","video_path":"video-del-err.mp4"}`,
 	}
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{
 			{
-				Body:          &mockMsg.body,
-				ReceiptHandle: &mockMsg.receipt,
+				Body:    []byte(mockMsg.body),
+				Attempt: 1,
+				Acker:   suite.mockAcker,
 			},
 		}, nil).
 		Times(1)
 
 	suite.mockQueue.EXPECT().
-		Receive(gomock.Any(), int32(10), int32(20)).
-		Return([]types.Message{}, nil).
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
 		AnyTimes()
 
 	suite.mockService.EXPECT().
-		ProcessJob(gomock.Any(), mockMsg.jobID).
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: mockMsg.jobID}, 1).
 		Return(nil).
 		Times(1)
 
-	suite.mockQueue.EXPECT().
-		Delete(gomock.Any(), mockMsg.receipt).
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
 		Return(assert.AnError).
 		Times(1)
 
@@ -263,3 +337,452 @@ func (suite *consumerTestSuite) Test_Start_QueueDeleteError() {
 
 	suite.consumer.Start(ctx)
 }
+
+func (suite *consumerTestSuite) Test_Start_ReceivesOnlyForFreeWorkers() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumer := input.NewConsumer(suite.mockQueue, suite.mockService, suite.mockMetrics, input.ConsumerConfig{Concurrency: 2})
+
+	bodies := []string{`{"job_id":"job-1"}`, `{"job_id":"job-2"}`}
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 2, 20*time.Second).
+		Return([]domain.Message{
+			{Body: []byte(bodies[0]), Attempt: 1, Acker: suite.mockAcker},
+			{Body: []byte(bodies[1]), Attempt: 1, Acker: suite.mockAcker},
+		}, nil).
+		Times(1)
+
+	release := make(chan struct{})
+	var done sync.WaitGroup
+	done.Add(2)
+
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), gomock.Any(), 1).
+		DoAndReturn(func(context.Context, domain.JobMessageEvent, int) error {
+			<-release
+			return nil
+		}).
+		Times(2)
+
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
+		DoAndReturn(func(context.Context) error {
+			done.Done()
+			return nil
+		}).
+		Times(2)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	// Both workers are busy, so Start must not call Receive again before the
+	// context is cancelled.
+	consumer.Start(ctx)
+
+	close(release)
+	done.Wait()
+}
+
+func (suite *consumerTestSuite) Test_Shutdown_DrainsInFlightJobs() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := `{"job_id":"job-drain"}`
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{{Body: []byte(body), Attempt: 1, Acker: suite.mockAcker}}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		AnyTimes()
+
+	started := make(chan struct{})
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-drain"}, 1).
+		DoAndReturn(func(context.Context, domain.JobMessageEvent, int) error {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		}).
+		Times(1)
+
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
+		Return(nil).
+		Times(1)
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	suite.consumer.Start(ctx)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Second)
+	defer drainCancel()
+	suite.NoError(suite.consumer.Shutdown(drainCtx))
+}
+
+func (suite *consumerTestSuite) Test_Shutdown_CancelsJobsOnTimeout() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := `{"job_id":"job-slow"}`
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{{Body: []byte(body), Attempt: 1, Acker: suite.mockAcker}}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		AnyTimes()
+
+	started := make(chan struct{})
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-slow"}, 1).
+		DoAndReturn(func(ctx context.Context, _ domain.JobMessageEvent, _ int) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		Times(1)
+
+	// No Ack expectation: the message must stay on the queue.
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	suite.consumer.Start(ctx)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer drainCancel()
+	suite.ErrorIs(suite.consumer.Shutdown(drainCtx), context.DeadlineExceeded)
+}
+
+func (suite *consumerTestSuite) Test_Start_ExtendsVisibilityWhileJobRuns() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumer := input.NewConsumer(suite.mockQueue, suite.mockService, suite.mockMetrics, input.ConsumerConfig{
+		Concurrency:       1,
+		VisibilityTimeout: 2 * time.Minute,
+		HeartbeatInterval: 20 * time.Millisecond,
+	})
+
+	body := `{"job_id":"job-long"}`
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 1, 20*time.Second).
+		Return([]domain.Message{{Body: []byte(body), Attempt: 1, Acker: suite.mockAcker}}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		AnyTimes()
+
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-long"}, 1).
+		DoAndReturn(func(context.Context, domain.JobMessageEvent, int) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		}).
+		Times(1)
+
+	suite.mockAcker.EXPECT().
+		Extend(gomock.Any(), 2*time.Minute).
+		Return(nil).
+		MinTimes(2)
+
+	done := make(chan struct{})
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
+		DoAndReturn(func(context.Context) error {
+			close(done)
+			return nil
+		}).
+		Times(1)
+
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	consumer.Start(ctx)
+	suite.NoError(consumer.Shutdown(context.Background()))
+}
+
+func (suite *consumerTestSuite) Test_Start_RecordsMetrics() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := mocks.NewMockMetricsRecorder(gomock.NewController(suite.T()))
+	consumer := input.NewConsumer(suite.mockQueue, suite.mockService, metrics, input.ConsumerConfig{Concurrency: 10})
+	body := `{"job_id":"job-123"}`
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{{Body: []byte(body), Attempt: 1, Acker: suite.mockAcker}}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		AnyTimes()
+
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-123"}, 1).
+		DoAndReturn(func(context.Context, domain.JobMessageEvent, int) error {
+			cancel()
+			return nil
+		})
+	suite.mockAcker.EXPECT().Ack(gomock.Any()).Return(nil)
+
+	gomock.InOrder(
+		metrics.EXPECT().JobReceived(),
+		metrics.EXPECT().JobStarted(),
+		metrics.EXPECT().JobFinished(),
+	)
+
+	consumer.Start(ctx)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Second)
+	defer drainCancel()
+	suite.NoError(consumer.Shutdown(drainCtx))
+}
+
+func (suite *consumerTestSuite) Test_Start_RecordsReceiveErrors() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	metrics := mocks.NewMockMetricsRecorder(gomock.NewController(suite.T()))
+	consumer := input.NewConsumer(suite.mockQueue, suite.mockService, metrics, input.ConsumerConfig{Concurrency: 10})
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return(nil, assert.AnError).
+		Times(1)
+
+	// The loop may still try once more before it notices the cancellation.
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+			return nil, ctx.Err()
+		}).
+		AnyTimes()
+
+	metrics.EXPECT().ReceiveFailed().Do(cancel)
+
+	consumer.Start(ctx)
+}
+
+func (suite *consumerTestSuite) Test_Start_ContinuesUpstreamTrace() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	body := `{"job_id":"job-traced"}`
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{{
+			Body:       []byte(body),
+			Attributes: map[string]string{"traceparent": traceparent},
+			Attempt:    1,
+			Acker:      suite.mockAcker,
+		}}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
+		AnyTimes()
+
+	var traceID trace.TraceID
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-traced"}, 1).
+		DoAndReturn(func(ctx context.Context, _ domain.JobMessageEvent, _ int) error {
+			traceID = trace.SpanContextFromContext(ctx).TraceID()
+			return nil
+		}).
+		Times(1)
+
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
+		Return(nil).
+		Times(1)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	suite.consumer.Start(ctx)
+
+	suite.Equal("4bf92f3577b34da6a3ce929d0e0e4736", traceID.String())
+}
+
+func (suite *consumerTestSuite) Test_Start_LogsMessageAndJobAttributes() {
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background(), logger.New(&buf, slog.LevelInfo)))
+	defer cancel()
+
+	body := `{"job_id":"job-logged"}`
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), 10, 20*time.Second).
+		Return([]domain.Message{{
+			ID:      "msg-logged",
+			Body:    []byte(body),
+			Attempt: 2,
+			Acker:   suite.mockAcker,
+		}}, nil).
+		Times(1)
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		Return([]domain.Message{}, nil).
+		AnyTimes()
+
+	suite.mockService.EXPECT().
+		ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-logged"}, 2).
+		DoAndReturn(func(ctx context.Context, _ domain.JobMessageEvent, _ int) error {
+			logger.FromContext(ctx).Info("from the service")
+			return nil
+		}).
+		Times(1)
+
+	suite.mockAcker.EXPECT().
+		Ack(gomock.Any()).
+		Return(nil).
+		Times(1)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	suite.consumer.Start(ctx)
+	suite.Require().NoError(suite.consumer.Shutdown(context.Background()))
+
+	jobLines := 0
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		suite.Require().NoError(json.Unmarshal(line, &record), "expected JSON log lines")
+		if _, ok := record[logger.MessageIDKey]; !ok {
+			continue
+		}
+		jobLines++
+		suite.Equal("msg-logged", record[logger.MessageIDKey])
+		suite.EqualValues(2, record[logger.ReceiveCountKey])
+		suite.Equal("job-logged", record[logger.JobIDKey])
+	}
+	suite.GreaterOrEqual(jobLines, 3, "expected the start, service and delete lines to carry the job attributes")
+}
+
+func (suite *consumerTestSuite) Test_CheckReadiness_FailsOnceDraining() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	suite.mockQueue.EXPECT().
+		Receive(gomock.Any(), gomock.Any(), 20*time.Second).
+		DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		AnyTimes()
+
+	suite.NoError(suite.consumer.CheckReadiness(ctx))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	suite.consumer.Start(ctx)
+
+	suite.Error(suite.consumer.CheckReadiness(ctx))
+	suite.NoError(suite.consumer.CheckLiveness(ctx), "a draining worker must not be restarted")
+}
+
+func (suite *consumerTestSuite) Test_CheckLiveness() {
+	s := suite.T()
+
+	s.Run("should fail when the receive call hangs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		queue := mocks.NewMockQueueAdapter(gomock.NewController(t))
+		consumer := input.NewConsumer(queue, suite.mockService, suite.mockMetrics, input.ConsumerConfig{Concurrency: 1, StallTimeout: 50 * time.Millisecond})
+
+		queue.EXPECT().
+			Receive(gomock.Any(), 1, 20*time.Second).
+			DoAndReturn(func(ctx context.Context, _ int, _ time.Duration) ([]domain.Message, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+
+		go consumer.Start(ctx)
+
+		assert.Eventually(t, func() bool { return consumer.CheckLiveness(ctx) != nil }, time.Second, 10*time.Millisecond)
+	})
+
+	s.Run("should pass while every worker is busy", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		queue := mocks.NewMockQueueAdapter(gomock.NewController(t))
+		service := mocks.NewMockJobService(gomock.NewController(t))
+		acker := mocks.NewMockMessageAcker(gomock.NewController(t))
+		consumer := input.NewConsumer(queue, service, suite.mockMetrics, input.ConsumerConfig{Concurrency: 1, StallTimeout: 50 * time.Millisecond})
+		body := `{"job_id":"job-slow"}`
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		queue.EXPECT().
+			Receive(gomock.Any(), 1, 20*time.Second).
+			Return([]domain.Message{{Body: []byte(body), Attempt: 1, Acker: acker}}, nil)
+		service.EXPECT().
+			ProcessJob(gomock.Any(), domain.JobMessageEvent{JobID: "job-slow"}, 1).
+			DoAndReturn(func(context.Context, domain.JobMessageEvent, int) error {
+				close(started)
+				<-release
+				return nil
+			})
+		acker.EXPECT().Ack(gomock.Any()).Return(nil)
+
+		done := make(chan struct{})
+		go func() {
+			consumer.Start(ctx)
+			close(done)
+		}()
+		<-started
+		time.Sleep(100 * time.Millisecond)
+
+		assert.NoError(t, consumer.CheckLiveness(ctx))
+
+		cancel()
+		<-done
+		close(release)
+		assert.NoError(t, consumer.Shutdown(context.Background()))
+	})
+}
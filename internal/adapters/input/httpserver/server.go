@@ -0,0 +1,108 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// checkTimeout bounds each check, so a hung dependency fails the probe
+// instead of blocking it.
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a component the worker relies on is healthy.
+type Check func(ctx context.Context) error
+
+// Checks are the named checks behind the health endpoints.
+type Checks struct {
+	// Liveness failing means the process is wedged and should be restarted.
+	Liveness map[string]Check
+	// Readiness failing means the worker can't take jobs right now.
+	Readiness map[string]Check
+}
+
+// Server exposes the worker's operational endpoints.
+type Server struct {
+	server *http.Server
+}
+
+func NewServer(addr string, gatherer prometheus.Gatherer, checks Checks) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	mux.Handle("GET /healthz", checksHandler(checks.Liveness))
+	mux.Handle("GET /readyz", checksHandler(checks.Readiness))
+
+	return &Server{
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Start serves requests until Shutdown is called.
+func (s *Server) Start() error {
+	slog.Info("HTTP server listening.", "addr", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+type checksResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// checksHandler runs every check at the same time and answers 200 when all
+// of them pass or 503 otherwise, with the result of each one in the body.
+func checksHandler(checks map[string]Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := checksResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+				defer cancel()
+				err := check(ctx)
+
+				mu.Lock()
+				defer mu.Unlock()
+				resp.Checks[name] = "ok"
+				if err != nil {
+					resp.Checks[name] = err.Error()
+					resp.Status = "fail"
+				}
+			}()
+		}
+		wg.Wait()
+
+		w.Header().Set("Content-Type", "application/json")
+		if resp.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
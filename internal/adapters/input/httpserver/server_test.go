@@ -0,0 +1,110 @@
+package httpserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input/httpserver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "Test counter."})
+	registry.MustRegister(counter)
+	counter.Inc()
+
+	server := httptest.NewServer(httpserver.NewServer(":0", registry, httpserver.Checks{}).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "test_total 1")
+}
+
+func TestServer_Health(t *testing.T) {
+	passing := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("bucket not found") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		checks     httpserver.Checks
+		wantStatus int
+		wantBody   map[string]string
+	}{
+		{
+			name:       "LiveWhenChecksPass",
+			path:       "/healthz",
+			checks:     httpserver.Checks{Liveness: map[string]httpserver.Check{"consumer": passing}},
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]string{"consumer": "ok"},
+		},
+		{
+			name: "ReadyWhenChecksPass",
+			path: "/readyz",
+			checks: httpserver.Checks{
+				Liveness:  map[string]httpserver.Check{"consumer": failing},
+				Readiness: map[string]httpserver.Check{"postgres": passing, "s3": passing},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]string{"postgres": "ok", "s3": "ok"},
+		},
+		{
+			name:       "NotReadyWhenACheckFails",
+			path:       "/readyz",
+			checks:     httpserver.Checks{Readiness: map[string]httpserver.Check{"postgres": passing, "s3": failing}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   map[string]string{"postgres": "ok", "s3": "bucket not found"},
+		},
+		{
+			name:       "NotReadyWhenACheckHangs",
+			path:       "/readyz",
+			checks:     httpserver.Checks{Readiness: map[string]httpserver.Check{"sqs": hanging}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   map[string]string{"sqs": context.DeadlineExceeded.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(httpserver.NewServer(":0", prometheus.NewRegistry(), tt.checks).Handler())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var body struct {
+				Status string            `json:"status"`
+				Checks map[string]string `json:"checks"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, body.Checks)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "ok", body.Status)
+			} else {
+				assert.Equal(t, "fail", body.Status)
+			}
+		})
+	}
+}
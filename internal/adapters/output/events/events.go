@@ -0,0 +1,20 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// eventTypeAttribute is the message attribute carrying the event type, so
+// subscribers can filter events without parsing the body.
+const eventTypeAttribute = "event_type"
+
+func encode(event domain.JobEvent) (string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize '%s' event to JSON: %w", event.Header().Type, err)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"context"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+type noopPublisher struct{}
+
+// NewNoopPublisher drops every event, for when no results queue or topic is
+// set up.
+func NewNoopPublisher() *noopPublisher {
+	return &noopPublisher{}
+}
+
+func (p *noopPublisher) Publish(context.Context, domain.JobEvent) error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+type snsPublisher struct {
+	client   ports.SNSClient
+	topicARN string
+}
+
+// NewSNSPublisher sends lifecycle events to a topic, so each interested
+// service can subscribe its own queue and filter on the event type.
+func NewSNSPublisher(client ports.SNSClient, topicARN string) *snsPublisher {
+	return &snsPublisher{client: client, topicARN: topicARN}
+}
+
+func (p *snsPublisher) Publish(ctx context.Context, event domain.JobEvent) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	attributes := tracing.SNSCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, attributes)
+	attributes.Set(eventTypeAttribute, string(event.Header().Type))
+
+	_, err = p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(p.topicARN),
+		Message:           aws.String(body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish '%s' event to SNS: %w", event.Header().Type, err)
+	}
+	return nil
+}
+
+// Ping checks that the topic exists and can be reached with the worker's
+// credentials.
+func (p *snsPublisher) Ping(ctx context.Context) error {
+	_, err := p.client.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(p.topicARN)})
+	if err != nil {
+		return fmt.Errorf("failed to get attributes of topic '%s': %w", p.topicARN, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/events"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const topicARN = "arn:aws:sns:us-east-1:000000000000:job-events"
+
+func TestSNSPublisher_Publish(t *testing.T) {
+	t.Run("PublishesTheEventToTheTopic", func(t *testing.T) {
+		failed := domain.JobFailedEvent{
+			JobEventHeader: domain.JobEventHeader{Type: domain.JobEventFailed, JobID: "job-123"},
+			Reason:         domain.FailureInvalidVideo,
+			Attempt:        1,
+		}
+		client := mocks.NewMockSNSClient(gomock.NewController(t))
+		client.EXPECT().
+			Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+				assert.Equal(t, topicARN, aws.ToString(input.TopicArn))
+				assert.Equal(t, "String", aws.ToString(input.MessageAttributes["event_type"].DataType))
+				assert.Equal(t, "job.failed", aws.ToString(input.MessageAttributes["event_type"].StringValue))
+
+				var body map[string]any
+				require.NoError(t, json.Unmarshal([]byte(aws.ToString(input.Message)), &body))
+				assert.Equal(t, "job.failed", body["type"])
+				assert.Equal(t, "invalid_video", body["reason"])
+				assert.EqualValues(t, 1, body["attempt"])
+				return &sns.PublishOutput{}, nil
+			})
+
+		err := events.NewSNSPublisher(client, topicARN).Publish(context.Background(), failed)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("PublishFails", func(t *testing.T) {
+		client := mocks.NewMockSNSClient(gomock.NewController(t))
+		client.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil, errors.New("throttled"))
+
+		err := events.NewSNSPublisher(client, topicARN).Publish(context.Background(), completedEvent)
+
+		assert.ErrorContains(t, err, "failed to publish 'job.completed' event to SNS")
+	})
+}
+
+func TestSNSPublisher_Ping(t *testing.T) {
+	client := mocks.NewMockSNSClient(gomock.NewController(t))
+	client.EXPECT().
+		GetTopicAttributes(gomock.Any(), &sns.GetTopicAttributesInput{TopicArn: aws.String(topicARN)}).
+		Return(&sns.GetTopicAttributesOutput{}, nil)
+
+	assert.NoError(t, events.NewSNSPublisher(client, topicARN).Ping(context.Background()))
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+)
+
+type sqsPublisher struct {
+	client   ports.SQSClient
+	queueURL string
+}
+
+// NewSQSPublisher sends lifecycle events to a results queue.
+func NewSQSPublisher(client ports.SQSClient, queueURL string) *sqsPublisher {
+	return &sqsPublisher{client: client, queueURL: queueURL}
+}
+
+func (p *sqsPublisher) Publish(ctx context.Context, event domain.JobEvent) error {
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	attributes := tracing.SQSCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, attributes)
+	attributes.Set(eventTypeAttribute, string(event.Header().Type))
+
+	_, err = p.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(p.queueURL),
+		MessageBody:       aws.String(body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish '%s' event to SQS: %w", event.Header().Type, err)
+	}
+	return nil
+}
+
+// Ping checks that the results queue exists and can be reached with the
+// worker's credentials.
+func (p *sqsPublisher) Ping(ctx context.Context) error {
+	_, err := p.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(p.queueURL),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get attributes of queue '%s': %w", p.queueURL, err)
+	}
+	return nil
+}
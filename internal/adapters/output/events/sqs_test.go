@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/events"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var completedEvent = domain.JobCompletedEvent{
+	JobEventHeader: domain.JobEventHeader{
+		Type:       domain.JobEventCompleted,
+		JobID:      "job-123",
+		UserID:     "user-123",
+		WorkerID:   "worker-1",
+		OccurredAt: time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+	},
+	OutputPath:        "output/job-123.zip",
+	FrameCount:        10,
+	ArchiveSizeBytes:  1024,
+	DurationMs:        5000,
+	ExtractDurationMs: 3000,
+	ZipDurationMs:     1000,
+}
+
+func TestSQSPublisher_Publish(t *testing.T) {
+	t.Run("SendsTheEventToTheResultsQueue", func(t *testing.T) {
+		client := mocks.NewMockSQSClient(gomock.NewController(t))
+		client.EXPECT().
+			SendMessage(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				assert.Equal(t, "resultsQueueURL", aws.ToString(input.QueueUrl))
+				assert.Equal(t, "job.completed", aws.ToString(input.MessageAttributes["event_type"].StringValue))
+
+				var body map[string]any
+				require.NoError(t, json.Unmarshal([]byte(aws.ToString(input.MessageBody)), &body))
+				assert.Equal(t, "job.completed", body["type"])
+				assert.Equal(t, "job-123", body["job_id"])
+				assert.Equal(t, "worker-1", body["worker_id"])
+				assert.Equal(t, "2023-10-01T12:00:00Z", body["occurred_at"])
+				assert.Equal(t, "output/job-123.zip", body["output_path"])
+				assert.EqualValues(t, 10, body["frame_count"])
+				assert.EqualValues(t, 1024, body["archive_size_bytes"])
+				assert.EqualValues(t, 3000, body["extract_duration_ms"])
+				assert.NotContains(t, body, "download_url")
+				return &sqs.SendMessageOutput{}, nil
+			})
+
+		err := events.NewSQSPublisher(client, "resultsQueueURL").Publish(context.Background(), completedEvent)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("SendMessageFails", func(t *testing.T) {
+		client := mocks.NewMockSQSClient(gomock.NewController(t))
+		client.EXPECT().SendMessage(gomock.Any(), gomock.Any()).Return(nil, errors.New("send error"))
+
+		err := events.NewSQSPublisher(client, "resultsQueueURL").Publish(context.Background(), completedEvent)
+
+		assert.ErrorContains(t, err, "failed to publish 'job.completed' event to SQS")
+	})
+}
+
+func TestSQSPublisher_Ping(t *testing.T) {
+	client := mocks.NewMockSQSClient(gomock.NewController(t))
+	client.EXPECT().
+		GetQueueAttributes(gomock.Any(), gomock.Any()).
+		Return(nil, &types.QueueDoesNotExist{})
+
+	err := events.NewSQSPublisher(client, "resultsQueueURL").Ping(context.Background())
+
+	assert.ErrorContains(t, err, "failed to get attributes of queue 'resultsQueueURL'")
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "process_worker"
+
+type prometheusRecorder struct {
+	jobsReceived    prometheus.Counter
+	jobsSucceeded   prometheus.Counter
+	jobsFailed      *prometheus.CounterVec
+	jobsInFlight    prometheus.Gauge
+	jobDuration     prometheus.Histogram
+	stageDuration   *prometheus.HistogramVec
+	bytesDownloaded prometheus.Counter
+	bytesUploaded   prometheus.Counter
+	receiveErrors   prometheus.Counter
+}
+
+// NewPrometheusRecorder creates the worker metrics and registers them with
+// registerer.
+func NewPrometheusRecorder(registerer prometheus.Registerer) *prometheusRecorder {
+	// From 100ms to about 14 minutes.
+	buckets := prometheus.ExponentialBuckets(0.1, 2, 14)
+
+	r := &prometheusRecorder{
+		jobsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_received_total",
+			Help:      "Job messages received from the queue.",
+		}),
+		jobsSucceeded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_succeeded_total",
+			Help:      "Jobs processed successfully.",
+		}),
+		jobsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_failed_total",
+			Help:      "Job attempts that failed, by reason and whether the job will be retried.",
+		}, []string{"reason", "retrying"}),
+		jobsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "jobs_in_flight",
+			Help:      "Jobs currently being processed.",
+		}),
+		jobDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Time taken to process a job successfully.",
+			Buckets:   buckets,
+		}),
+		stageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "stage_duration_seconds",
+			Help:      "Time taken by each step of a job: download, probe, ffmpeg, zip and upload.",
+			Buckets:   buckets,
+		}, []string{"stage"}),
+		bytesDownloaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "downloaded_bytes_total",
+			Help:      "Bytes of input videos downloaded.",
+		}),
+		bytesUploaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "uploaded_bytes_total",
+			Help:      "Bytes of frame archives uploaded.",
+		}),
+		receiveErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sqs_receive_errors_total",
+			Help:      "Failed attempts to receive messages from the queue.",
+		}),
+	}
+
+	registerer.MustRegister(
+		r.jobsReceived,
+		r.jobsSucceeded,
+		r.jobsFailed,
+		r.jobsInFlight,
+		r.jobDuration,
+		r.stageDuration,
+		r.bytesDownloaded,
+		r.bytesUploaded,
+		r.receiveErrors,
+	)
+	return r
+}
+
+func (r *prometheusRecorder) JobReceived() {
+	r.jobsReceived.Inc()
+}
+
+func (r *prometheusRecorder) JobStarted() {
+	r.jobsInFlight.Inc()
+}
+
+func (r *prometheusRecorder) JobFinished() {
+	r.jobsInFlight.Dec()
+}
+
+func (r *prometheusRecorder) JobSucceeded(duration time.Duration) {
+	r.jobsSucceeded.Inc()
+	r.jobDuration.Observe(duration.Seconds())
+}
+
+func (r *prometheusRecorder) JobFailed(reason domain.FailureReason, retrying bool) {
+	r.jobsFailed.WithLabelValues(string(reason), strconv.FormatBool(retrying)).Inc()
+}
+
+func (r *prometheusRecorder) ObserveStage(stage string, duration time.Duration) {
+	r.stageDuration.WithLabelValues(stage).Observe(duration.Seconds())
+}
+
+func (r *prometheusRecorder) AddBytesDownloaded(n int64) {
+	r.bytesDownloaded.Add(float64(n))
+}
+
+func (r *prometheusRecorder) AddBytesUploaded(n int64) {
+	r.bytesUploaded.Add(float64(n))
+}
+
+func (r *prometheusRecorder) ReceiveFailed() {
+	r.receiveErrors.Inc()
+}
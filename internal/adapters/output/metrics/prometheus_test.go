@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusRecorder(t *testing.T) {
+	t.Run("should count jobs by outcome", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		recorder := metrics.NewPrometheusRecorder(registry)
+
+		recorder.JobReceived()
+		recorder.JobReceived()
+		recorder.JobSucceeded(2 * time.Second)
+		recorder.JobFailed("upload", true)
+		recorder.JobFailed("invalid_video", false)
+		recorder.ReceiveFailed()
+
+		err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP process_worker_jobs_received_total Job messages received from the queue.
+# TYPE process_worker_jobs_received_total counter
+process_worker_jobs_received_total 2
+# HELP process_worker_jobs_succeeded_total Jobs processed successfully.
+# TYPE process_worker_jobs_succeeded_total counter
+process_worker_jobs_succeeded_total 1
+# HELP process_worker_jobs_failed_total Job attempts that failed, by reason and whether the job will be retried.
+# TYPE process_worker_jobs_failed_total counter
+process_worker_jobs_failed_total{reason="invalid_video",retrying="false"} 1
+process_worker_jobs_failed_total{reason="upload",retrying="true"} 1
+# HELP process_worker_sqs_receive_errors_total Failed attempts to receive messages from the queue.
+# TYPE process_worker_sqs_receive_errors_total counter
+process_worker_sqs_receive_errors_total 1
+`),
+			"process_worker_jobs_received_total",
+			"process_worker_jobs_succeeded_total",
+			"process_worker_jobs_failed_total",
+			"process_worker_sqs_receive_errors_total",
+		)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should track jobs in flight", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		recorder := metrics.NewPrometheusRecorder(registry)
+
+		recorder.JobStarted()
+		recorder.JobStarted()
+		recorder.JobFinished()
+
+		err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP process_worker_jobs_in_flight Jobs currently being processed.
+# TYPE process_worker_jobs_in_flight gauge
+process_worker_jobs_in_flight 1
+`), "process_worker_jobs_in_flight")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should observe stage durations and count bytes", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		recorder := metrics.NewPrometheusRecorder(registry)
+
+		recorder.ObserveStage("download", time.Second)
+		recorder.ObserveStage("upload", 3*time.Second)
+		recorder.AddBytesDownloaded(1024)
+		recorder.AddBytesUploaded(512)
+
+		count, err := testutil.GatherAndCount(registry, "process_worker_stage_duration_seconds")
+		require.NoError(t, err)
+		assert.Equal(t, 2, count, "expected one series per stage")
+		err = testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP process_worker_downloaded_bytes_total Bytes of input videos downloaded.
+# TYPE process_worker_downloaded_bytes_total counter
+process_worker_downloaded_bytes_total 1024
+# HELP process_worker_uploaded_bytes_total Bytes of frame archives uploaded.
+# TYPE process_worker_uploaded_bytes_total counter
+process_worker_uploaded_bytes_total 512
+`), "process_worker_downloaded_bytes_total", "process_worker_uploaded_bytes_total")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+type noopNotifier struct{}
+
+// NewNoopNotifier drops every notification, for when email is not set up.
+func NewNoopNotifier() *noopNotifier {
+	return &noopNotifier{}
+}
+
+func (n *noopNotifier) JobCompleted(context.Context, *domain.VideoJobDTO, *domain.DownloadLink) error {
+	return nil
+}
+
+func (n *noopNotifier) JobFailed(context.Context, *domain.VideoJobDTO, domain.FailureReason) error {
+	return nil
+}
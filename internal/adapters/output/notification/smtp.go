@@ -0,0 +1,183 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"path"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// Each template defines a "subject" and a "body".
+var (
+	completedTemplate = template.Must(template.ParseFS(templateFiles, "templates/job_completed.tmpl"))
+	failedTemplate    = template.Must(template.ParseFS(templateFiles, "templates/job_failed.tmpl"))
+)
+
+// failureMessages explain to users why their job failed. Reasons not listed
+// are failures on our side that the user can't do anything about.
+var failureMessages = map[domain.FailureReason]string{
+	domain.FailureInvalidOptions: "As opções de extração de frames enviadas são inválidas.",
+	domain.FailureVideoNotFound:  "O vídeo enviado não foi encontrado.",
+	domain.FailureInvalidVideo:   "O arquivo enviado não é um vídeo válido ou está corrompido.",
+	domain.FailureLimitExceeded:  "O vídeo excede os limites de duração, tamanho ou resolução aceitos.",
+	domain.FailureProcessing:     "Não foi possível extrair os frames do vídeo.",
+}
+
+const defaultFailureMessage = "Ocorreu um erro inesperado ao processar o vídeo. Tente enviá-lo novamente mais tarde."
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender address, optionally with a display name.
+	From string
+}
+
+type smtpNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier sends notifications by email. STARTTLS is used when the
+// server offers it, and credentials are only sent when Username is set, so
+// local sinks such as MailHog work without either.
+func NewSMTPNotifier(cfg SMTPConfig) *smtpNotifier {
+	return &smtpNotifier{cfg: cfg}
+}
+
+func (n *smtpNotifier) JobCompleted(ctx context.Context, job *domain.VideoJobDTO, download *domain.DownloadLink) error {
+	data := map[string]string{
+		"JobID":     job.ID,
+		"VideoName": path.Base(job.VideoPath),
+	}
+	if download != nil {
+		data["DownloadURL"] = download.URL
+		data["ExpiresAt"] = download.ExpiresAt.UTC().Format("02/01/2006 15:04 MST")
+	}
+	return n.send(ctx, job.Email, completedTemplate, data)
+}
+
+func (n *smtpNotifier) JobFailed(ctx context.Context, job *domain.VideoJobDTO, reason domain.FailureReason) error {
+	message, ok := failureMessages[reason]
+	if !ok {
+		message = defaultFailureMessage
+	}
+	return n.send(ctx, job.Email, failedTemplate, map[string]string{
+		"JobID":     job.ID,
+		"VideoName": path.Base(job.VideoPath),
+		"Reason":    message,
+	})
+}
+
+func (n *smtpNotifier) send(ctx context.Context, to string, tmpl *template.Template, data map[string]string) error {
+	from, err := mail.ParseAddress(n.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address '%s': %w", n.cfg.From, err)
+	}
+	// Parsing also keeps a malformed address from injecting headers.
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address '%s': %w", to, err)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("failed to render email subject: %w", err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return fmt.Errorf("failed to render email body: %w", err)
+	}
+
+	msg, err := buildMessage(from, rcpt, subject.String(), body.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := n.deliver(ctx, from.Address, rcpt.Address, msg); err != nil {
+		return fmt.Errorf("failed to send email to '%s': %w", rcpt.Address, err)
+	}
+	return nil
+}
+
+func buildMessage(from, to *mail.Address, subject string, body []byte) ([]byte, error) {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write(bytes.ReplaceAll(body, []byte("\n"), []byte("\r\n"))); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	return msg.Bytes(), nil
+}
+
+// deliver runs a single SMTP transaction. net/smtp has no context support,
+// so the context's deadline is applied to the connection instead.
+func (n *smtpNotifier) deliver(ctx context.Context, from, to string, msg []byte) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
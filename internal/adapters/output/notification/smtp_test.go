@@ -0,0 +1,187 @@
+package notification_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/notification"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data []byte
+}
+
+// startSMTPSink runs a bare SMTP server, like MailHog, that accepts every
+// message and hands it to the returned channel.
+func startSMTPSink(t *testing.T) (string, int, <-chan receivedMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan receivedMail, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, received)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func serveSMTP(conn net.Conn, received chan<- receivedMail) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	var msg receivedMail
+	_ = text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = text.PrintfLine("250 localhost")
+		case "MAIL":
+			msg.from = arg
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, arg)
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 Send message")
+			msg.data, err = text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			received <- msg
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			return
+		default:
+			_ = text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func readMail(t *testing.T, received <-chan receivedMail) (receivedMail, string, string) {
+	var msg receivedMail
+	select {
+	case msg = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("no email received")
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg.data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=UTF-8", parsed.Header.Get("Content-Type"))
+	return msg, subject, string(body)
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	job := &domain.VideoJobDTO{
+		ID:        "job-123",
+		Email:     "user@example.com",
+		VideoPath: "upload/ferias.mp4",
+	}
+
+	t.Run("JobCompleted", func(t *testing.T) {
+		host, port, received := startSMTPSink(t)
+		notifier := notification.NewSMTPNotifier(notification.SMTPConfig{Host: host, Port: port, From: "Process Worker <worker@example.com>"})
+
+		err := notifier.JobCompleted(context.Background(), job, &domain.DownloadLink{
+			URL:       "https://bucket-videos.s3.amazonaws.com/output/job-123.zip?X-Amz-Signature=abc",
+			ExpiresAt: time.Date(2023, 10, 2, 15, 4, 0, 0, time.UTC),
+		})
+
+		require.NoError(t, err)
+		msg, subject, body := readMail(t, received)
+		assert.Equal(t, "FROM:<worker@example.com>", msg.from)
+		assert.Equal(t, []string{"TO:<user@example.com>"}, msg.to)
+		assert.Equal(t, "Seus frames estão prontos", subject)
+		assert.Contains(t, body, "ferias.mp4")
+		assert.Contains(t, body, "https://bucket-videos.s3.amazonaws.com/output/job-123.zip?X-Amz-Signature=abc")
+		assert.Contains(t, body, "válido até 02/10/2023 15:04 UTC")
+		assert.Contains(t, body, "job-123")
+	})
+
+	t.Run("JobCompletedWithoutLink", func(t *testing.T) {
+		host, port, received := startSMTPSink(t)
+		notifier := notification.NewSMTPNotifier(notification.SMTPConfig{Host: host, Port: port, From: "worker@example.com"})
+
+		err := notifier.JobCompleted(context.Background(), job, nil)
+
+		require.NoError(t, err)
+		_, _, body := readMail(t, received)
+		assert.Contains(t, body, "Acesse a plataforma")
+	})
+
+	t.Run("JobFailed", func(t *testing.T) {
+		host, port, received := startSMTPSink(t)
+		notifier := notification.NewSMTPNotifier(notification.SMTPConfig{Host: host, Port: port, From: "worker@example.com"})
+
+		err := notifier.JobFailed(context.Background(), job, domain.FailureInvalidVideo)
+
+		require.NoError(t, err)
+		_, subject, body := readMail(t, received)
+		assert.Equal(t, "Não foi possível processar seu vídeo", subject)
+		assert.Contains(t, body, "não é um vídeo válido")
+	})
+
+	t.Run("JobFailedOnOurSide", func(t *testing.T) {
+		host, port, received := startSMTPSink(t)
+		notifier := notification.NewSMTPNotifier(notification.SMTPConfig{Host: host, Port: port, From: "worker@example.com"})
+
+		err := notifier.JobFailed(context.Background(), job, domain.FailureUpload)
+
+		require.NoError(t, err)
+		_, _, body := readMail(t, received)
+		assert.Contains(t, body, "erro inesperado")
+	})
+
+	t.Run("InvalidRecipient", func(t *testing.T) {
+		notifier := notification.NewSMTPNotifier(notification.SMTPConfig{Host: "127.0.0.1", Port: 1, From: "worker@example.com"})
+
+		err := notifier.JobFailed(context.Background(), &domain.VideoJobDTO{ID: "job-123", Email: "user@example.com\r\nBcc: x@example.com"}, domain.FailureProcessing)
+
+		assert.ErrorContains(t, err, "invalid recipient address")
+	})
+
+	t.Run("ServerUnreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+		notifier := notification.NewSMTPNotifier(notification.SMTPConfig{Host: "127.0.0.1", Port: port, From: "worker@example.com"})
+
+		err = notifier.JobCompleted(context.Background(), job, nil)
+
+		assert.ErrorContains(t, err, "failed to send email to 'user@example.com'")
+		assert.ErrorContains(t, err, strconv.Itoa(port))
+	})
+}
@@ -0,0 +1,212 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// maxFrameBytes bounds a single encoded frame so a corrupt stream can't make
+// the reader allocate without limit.
+const maxFrameBytes = 256 << 20
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// FrameReader splits the concatenated images written by ffmpeg's image2pipe
+// muxer back into individual frames.
+type FrameReader struct {
+	r    *bufio.Reader
+	next func(r *bufio.Reader) ([]byte, error)
+}
+
+func NewFrameReader(r io.Reader, format domain.ImageFormat) (*FrameReader, error) {
+	fr := &FrameReader{r: bufio.NewReaderSize(r, 64<<10)}
+	switch format {
+	case domain.ImageFormatPNG:
+		fr.next = readPNG
+	case domain.ImageFormatJPG:
+		fr.next = readJPEG
+	case domain.ImageFormatWEBP:
+		fr.next = readWEBP
+	default:
+		return nil, fmt.Errorf("unsupported frame format '%s'", format)
+	}
+	return fr, nil
+}
+
+// Next returns the next complete frame. It returns io.EOF once the stream
+// ends between frames and io.ErrUnexpectedEOF if it ends inside one.
+func (fr *FrameReader) Next() ([]byte, error) {
+	return fr.next(fr.r)
+}
+
+func readPNG(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := copyFull(&buf, r, len(pngSignature)); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buf.Bytes(), pngSignature) {
+		return nil, errors.New("invalid PNG signature")
+	}
+
+	for {
+		// Each chunk is a 4 byte length and 4 byte type, then the data and a
+		// 4 byte CRC. IEND is always the last chunk.
+		start := buf.Len()
+		if err := copyFull(&buf, r, 8); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		header := buf.Bytes()[start:]
+		length := int(binary.BigEndian.Uint32(header[:4]))
+		chunkType := string(header[4:8])
+		if buf.Len()+length > maxFrameBytes {
+			return nil, fmt.Errorf("PNG frame larger than %d bytes", maxFrameBytes)
+		}
+		if err := copyFull(&buf, r, length+4); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if chunkType == "IEND" {
+			return buf.Bytes(), nil
+		}
+	}
+}
+
+func readJPEG(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := copyFull(&buf, r, 2); err != nil {
+		return nil, err
+	}
+	if buf.Bytes()[0] != 0xFF || buf.Bytes()[1] != 0xD8 {
+		return nil, errors.New("invalid JPEG start of image marker")
+	}
+
+	markerStarted := false
+	for {
+		if buf.Len() > maxFrameBytes {
+			return nil, fmt.Errorf("JPEG frame larger than %d bytes", maxFrameBytes)
+		}
+		if !markerStarted {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if b != 0xFF {
+				return nil, fmt.Errorf("invalid JPEG marker prefix 0x%02X", b)
+			}
+			buf.WriteByte(b)
+		}
+		markerStarted = false
+
+		marker, err := r.ReadByte()
+		for err == nil && marker == 0xFF {
+			// Fill bytes may pad a marker.
+			buf.WriteByte(marker)
+			marker, err = r.ReadByte()
+		}
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		buf.WriteByte(marker)
+
+		switch {
+		case marker == 0xD9:
+			return buf.Bytes(), nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Standalone markers carry no length.
+			continue
+		}
+
+		start := buf.Len()
+		if err := copyFull(&buf, r, 2); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		length := int(binary.BigEndian.Uint16(buf.Bytes()[start:]))
+		if length < 2 {
+			return nil, fmt.Errorf("invalid JPEG segment length %d", length)
+		}
+		if err := copyFull(&buf, r, length-2); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if marker == 0xDA {
+			// Start of scan: entropy-coded data follows until the next real
+			// marker, whose 0xFF prefix is consumed here.
+			if err := copyEntropyCodedData(&buf, r); err != nil {
+				return nil, err
+			}
+			markerStarted = true
+		}
+	}
+}
+
+func copyEntropyCodedData(buf *bytes.Buffer, r *bufio.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		buf.WriteByte(b)
+		if b != 0xFF {
+			continue
+		}
+
+		next, err := r.Peek(1)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		// 0xFF00 is an escaped data byte and RSTn markers sit inside the
+		// scan; anything else ends it.
+		if next[0] != 0x00 && (next[0] < 0xD0 || next[0] > 0xD7) {
+			return nil
+		}
+		_, _ = r.ReadByte()
+		buf.WriteByte(next[0])
+		if buf.Len() > maxFrameBytes {
+			return fmt.Errorf("JPEG frame larger than %d bytes", maxFrameBytes)
+		}
+	}
+}
+
+func readWEBP(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := copyFull(&buf, r, 12); err != nil {
+		return nil, err
+	}
+	header := buf.Bytes()
+	if string(header[:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+		return nil, errors.New("invalid WebP RIFF header")
+	}
+
+	// The RIFF size counts everything after the size field itself, including
+	// the "WEBP" tag already read.
+	size := int(binary.LittleEndian.Uint32(header[4:8]))
+	if size < 4 || size+8 > maxFrameBytes {
+		return nil, fmt.Errorf("invalid WebP frame size %d", size)
+	}
+	if err := copyFull(&buf, r, size-4); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// copyFull appends exactly n bytes from r to buf. It returns io.EOF only when
+// nothing could be read.
+func copyFull(buf *bytes.Buffer, r io.Reader, n int) error {
+	written, err := io.CopyN(buf, r, int64(n))
+	if err == io.EOF && written > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
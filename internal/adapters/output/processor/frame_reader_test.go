@@ -0,0 +1,112 @@
+package processor_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/processor"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+func testImage(shade uint8) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.Set(x, y, color.RGBA{R: shade, G: uint8(x * 4), B: uint8(y * 5), A: 255})
+		}
+	}
+	return img
+}
+
+func encodedFrames(t *testing.T, format domain.ImageFormat, count int) [][]byte {
+	t.Helper()
+	frames := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		var buf bytes.Buffer
+		switch format {
+		case domain.ImageFormatPNG:
+			if err := png.Encode(&buf, testImage(uint8(i*40))); err != nil {
+				t.Fatalf("failed to encode PNG: %v", err)
+			}
+		case domain.ImageFormatJPG:
+			if err := jpeg.Encode(&buf, testImage(uint8(i*40)), &jpeg.Options{Quality: 80}); err != nil {
+				t.Fatalf("failed to encode JPEG: %v", err)
+			}
+		case domain.ImageFormatWEBP:
+			// A minimal RIFF container is enough for the reader, which only
+			// relies on the header size. Odd payloads are padded.
+			payload := bytes.Repeat([]byte{byte(i + 1)}, 9+i)
+			if len(payload)%2 == 1 {
+				payload = append(payload, 0)
+			}
+			chunk := append([]byte("VP8L"), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+			chunk = append(chunk, payload...)
+			buf.WriteString("RIFF")
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(4+len(chunk))))
+			buf.WriteString("WEBP")
+			buf.Write(chunk)
+		}
+		frames = append(frames, buf.Bytes())
+	}
+	return frames
+}
+
+func TestFrameReader_Next(t *testing.T) {
+	for _, format := range []domain.ImageFormat{domain.ImageFormatPNG, domain.ImageFormatJPG, domain.ImageFormatWEBP} {
+		t.Run(string(format), func(t *testing.T) {
+			frames := encodedFrames(t, format, 3)
+			reader, err := processor.NewFrameReader(bytes.NewReader(bytes.Join(frames, nil)), format)
+			if err != nil {
+				t.Fatalf("NewFrameReader failed: %v", err)
+			}
+
+			for i, expected := range frames {
+				frame, err := reader.Next()
+				if err != nil {
+					t.Fatalf("Next failed on frame %d: %v", i+1, err)
+				}
+				if !bytes.Equal(expected, frame) {
+					t.Errorf("Frame %d differs: expected %d bytes, got %d", i+1, len(expected), len(frame))
+				}
+			}
+			if _, err := reader.Next(); err != io.EOF {
+				t.Errorf("Expected io.EOF after the last frame, got: %v", err)
+			}
+		})
+	}
+
+	t.Run("TruncatedFrame", func(t *testing.T) {
+		for _, format := range []domain.ImageFormat{domain.ImageFormatPNG, domain.ImageFormatJPG, domain.ImageFormatWEBP} {
+			frame := encodedFrames(t, format, 1)[0]
+			reader, err := processor.NewFrameReader(bytes.NewReader(frame[:len(frame)-3]), format)
+			if err != nil {
+				t.Fatalf("NewFrameReader failed: %v", err)
+			}
+			if _, err := reader.Next(); err != io.ErrUnexpectedEOF {
+				t.Errorf("%s: expected io.ErrUnexpectedEOF, got: %v", format, err)
+			}
+		}
+	})
+
+	t.Run("InvalidStream", func(t *testing.T) {
+		reader, err := processor.NewFrameReader(bytes.NewReader([]byte("definitely not a png")), domain.ImageFormatPNG)
+		if err != nil {
+			t.Fatalf("NewFrameReader failed: %v", err)
+		}
+		if _, err := reader.Next(); err == nil {
+			t.Error("Expected error for invalid stream, got nil")
+		}
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		if _, err := processor.NewFrameReader(bytes.NewReader(nil), "gif"); err == nil {
+			t.Error("Expected error for unsupported format, got nil")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// ffprobeOutput is the part of `ffprobe -print_format json` output we use.
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+		Disposition  struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// ProbeVideo reads the input's metadata with ffprobe. Files ffprobe cannot
+// parse, still images and files without a video stream are reported as
+// domain.ErrInvalidVideo.
+func (p *ffmpegProcessor) ProbeVideo(ctx context.Context, localVideoPath string) (*domain.VideoMetadata, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		localVideoPath,
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			return nil, domain.NewStageError(domain.StageProbe, stderr.String(), fmt.Errorf("%w: ffprobe could not read the file", domain.ErrInvalidVideo))
+		}
+		return nil, domain.NewStageError(domain.StageProbe, stderr.String(), fmt.Errorf("ffprobe execution error: %w", err))
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to decode ffprobe output: %w", err)
+	}
+
+	metadata := &domain.VideoMetadata{
+		FormatName:      probe.Format.FormatName,
+		DurationSeconds: parseFloat(probe.Format.Duration),
+		SizeBytes:       int64(parseFloat(probe.Format.Size)),
+		BitRate:         int64(parseFloat(probe.Format.BitRate)),
+	}
+	if metadata.SizeBytes == 0 {
+		if info, err := os.Stat(localVideoPath); err == nil {
+			metadata.SizeBytes = info.Size()
+		}
+	}
+
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 0 && metadata.VideoCodec == "":
+			metadata.VideoCodec = stream.CodecName
+			metadata.Width = stream.Width
+			metadata.Height = stream.Height
+			metadata.FrameRate = parseFrameRate(stream.AvgFrameRate)
+			if metadata.FrameRate == 0 {
+				metadata.FrameRate = parseFrameRate(stream.RFrameRate)
+			}
+		case stream.CodecType == "audio" && metadata.AudioCodec == "":
+			metadata.AudioCodec = stream.CodecName
+		}
+	}
+
+	if metadata.VideoCodec == "" {
+		return metadata, fmt.Errorf("%w: no video stream found", domain.ErrInvalidVideo)
+	}
+	// Still images are read by ffprobe as a single-frame video stream without
+	// a duration.
+	if strings.HasPrefix(metadata.FormatName, "image2") || strings.HasSuffix(metadata.FormatName, "_pipe") || metadata.DurationSeconds <= 0 {
+		return metadata, fmt.Errorf("%w: '%s' is a still image, not a video", domain.ErrInvalidVideo, metadata.FormatName)
+	}
+
+	return metadata, nil
+}
+
+func parseFloat(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// parseFrameRate parses ffprobe's rational frame rates, such as "30000/1001".
+func parseFrameRate(value string) float64 {
+	num, den, found := strings.Cut(value, "/")
+	if !found {
+		return parseFloat(value)
+	}
+	d := parseFloat(den)
+	if d == 0 {
+		return 0
+	}
+	return parseFloat(num) / d
+}
@@ -2,84 +2,207 @@ package processor
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+var tracer = otel.Tracer("github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/processor")
+
 type ffmpegProcessor struct{}
 
 func NewFFmpegProcessor() *ffmpegProcessor {
 	return &ffmpegProcessor{}
 }
 
-func (p *ffmpegProcessor) Process(ctx context.Context, localVideoPath string) (string, string, error) {
-	frameDir, err := os.MkdirTemp("", "frames-*")
+// Ping checks that the ffmpeg and ffprobe binaries can be found.
+func (p *ffmpegProcessor) Ping(_ context.Context) error {
+	for _, binary := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			return fmt.Errorf("%s not found: %w", binary, err)
+		}
+	}
+	return nil
+}
+
+// Process streams the frames ffmpeg extracts straight into a zip archive
+// written to output, so neither the frames nor the archive touch the disk.
+func (p *ffmpegProcessor) Process(ctx context.Context, localVideoPath string, opts domain.FrameOptions, output io.Writer) (*domain.ProcessedArchive, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", ffmpegArgs(localVideoPath, opts)...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create temp dir for frames: %w", err)
+		return nil, fmt.Errorf("failed to open ffmpeg output: %w", err)
+	}
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, domain.NewStageError(domain.StageExtract, "", fmt.Errorf("ffmpeg execution error: %w", err))
 	}
 
-	framePattern := filepath.Join(frameDir, "frame_%04d.png")
-	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", localVideoPath, "-vf", "fps=1", framePattern)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", "", fmt.Errorf("ffmpeg execution error: %w - output: %s", err, string(output))
+	_, zipSpan := tracer.Start(ctx, "zip")
+	archive, zipErr := zipFrames(stdout, output, opts.Format)
+	if zipErr == nil {
+		zipSpan.SetAttributes(
+			attribute.Int("zip.frame_count", archive.FrameCount),
+			attribute.Int64("zip.size_bytes", archive.SizeBytes),
+		)
+	}
+	tracing.End(zipSpan, zipErr)
+	if zipErr != nil {
+		// Stop ffmpeg instead of letting it block on a pipe nobody reads.
+		cancel()
+	}
+	// ffmpeg only writes to stderr when it fails, and then its error explains
+	// a truncated or empty stream better than the zip error does.
+	if err := cmd.Wait(); err != nil && (zipErr == nil || stderr.Len() > 0) {
+		return nil, domain.NewStageError(domain.StageExtract, stderr.String(), fmt.Errorf("ffmpeg execution error: %w", err))
+	}
+	if zipErr != nil {
+		return nil, domain.NewStageError(domain.StageZip, "", zipErr)
 	}
 
-	return zipFrames(frameDir)
+	archive.ExtractDuration = time.Since(started)
+	return archive, nil
 }
 
-func zipFrames(sourceDir string) (string, string, error) {
-	zipFile, err := os.CreateTemp("", "archive-*.zip")
-	if err != nil {
-		return "", "", fmt.Errorf("failed to create temp zip file: %w", err)
+// ffmpegArgs translates the frame options into the ffmpeg argument list.
+func ffmpegArgs(localVideoPath string, opts domain.FrameOptions) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin"}
+	if opts.StartSeconds > 0 {
+		// Seeking before the input is fast and resets timestamps, so the end
+		// becomes a duration relative to the start.
+		args = append(args, "-ss", formatSeconds(opts.StartSeconds))
+	}
+	args = append(args, "-i", localVideoPath)
+	if opts.EndSeconds > 0 {
+		args = append(args, "-t", formatSeconds(opts.EndSeconds-opts.StartSeconds))
 	}
 
-	zipWriter := zip.NewWriter(zipFile)
-
-	frames, err := filepath.Glob(filepath.Join(sourceDir, "*.png"))
-	if err != nil {
-		return "", "", fmt.Errorf("failed to find frames: %w", err)
+	filters := []string{"fps=" + formatSeconds(opts.Rate())}
+	if scale := scaleFilter(opts.MaxWidth, opts.MaxHeight); scale != "" {
+		filters = append(filters, scale)
 	}
-	if len(frames) == 0 {
-		return "", "", fmt.Errorf("no frames extracted")
+	args = append(args, "-vf", strings.Join(filters, ","))
+
+	switch opts.Format {
+	case domain.ImageFormatPNG:
+		args = append(args, "-c:v", "png")
+	case domain.ImageFormatJPG:
+		args = append(args, "-c:v", "mjpeg", "-q:v", strconv.Itoa(jpgQScale(opts.Quality)))
+	case domain.ImageFormatWEBP:
+		args = append(args, "-c:v", "libwebp", "-quality", strconv.Itoa(opts.Quality))
 	}
 
-	for _, framePath := range frames {
-		if err := addFileToZip(zipWriter, framePath); err != nil {
-			return "", "", fmt.Errorf("failed to add '%s' to zip: %w", framePath, err)
-		}
+	// Frames are written one after the other to stdout.
+	return append(args, "-f", "image2pipe", "-")
+}
+
+// scaleFilter shrinks frames to fit the given limits, never scaling them up.
+// Commas inside expressions are escaped so they don't split the filter chain.
+func scaleFilter(maxWidth, maxHeight int) string {
+	switch {
+	case maxWidth > 0 && maxHeight > 0:
+		return fmt.Sprintf(`scale=w=min(%d\,iw):h=min(%d\,ih):force_original_aspect_ratio=decrease`, maxWidth, maxHeight)
+	case maxWidth > 0:
+		return fmt.Sprintf(`scale=w=min(%d\,iw):h=-1`, maxWidth)
+	case maxHeight > 0:
+		return fmt.Sprintf(`scale=w=-1:h=min(%d\,ih)`, maxHeight)
+	default:
+		return ""
 	}
+}
+
+// jpgQScale maps a 1-100 quality to ffmpeg's JPEG qscale, where 2 is the
+// best and 31 the worst.
+func jpgQScale(quality int) int {
+	return 2 + (100-quality)*29/99
+}
 
-	fullPath := zipFile.Name()
-	fileName := filepath.Base(fullPath)
-	return fullPath, fileName, nil
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
 }
 
-func addFileToZip(zipWriter *zip.Writer, filename string) error {
-	fileToZip, err := os.Open(filename)
+// zipFrames writes every frame read from the ffmpeg output into a zip
+// archive written to output.
+func zipFrames(frames io.Reader, output io.Writer, format domain.ImageFormat) (*domain.ProcessedArchive, error) {
+	reader, err := NewFrameReader(frames, format)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	info, err := fileToZip.Stat()
-	if err != nil {
-		return err
+	counter := &countingWriter{w: output}
+	zipWriter := zip.NewWriter(counter)
+	frameCount := 0
+	var zipDuration time.Duration
+	for {
+		frame, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read frame %d: %w", frameCount+1, err)
+		}
+
+		frameCount++
+		name := fmt.Sprintf("frame_%04d.%s", frameCount, format)
+		zipStarted := time.Now()
+		if err := addFrameToZip(zipWriter, name, frame); err != nil {
+			return nil, fmt.Errorf("failed to add '%s' to zip: %w", name, err)
+		}
+		zipDuration += time.Since(zipStarted)
+	}
+	if frameCount == 0 {
+		return nil, fmt.Errorf("no frames extracted")
 	}
 
-	header, err := zip.FileInfoHeader(info)
-	if err != nil {
-		return err
+	// Close writes the central directory; without it the archive is unreadable.
+	zipStarted := time.Now()
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize zip file: %w", err)
 	}
-	header.Name = filepath.Base(filename)
-	header.Method = zip.Deflate
+	zipDuration += time.Since(zipStarted)
+
+	return &domain.ProcessedArchive{
+		FrameCount:  frameCount,
+		SizeBytes:   counter.n,
+		ZipDuration: zipDuration,
+	}, nil
+}
 
-	writer, err := zipWriter.CreateHeader(header)
+func addFrameToZip(zipWriter *zip.Writer, name string, frame []byte) error {
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(writer, fileToZip)
+	_, err = writer.Write(frame)
 	return err
 }
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -1,15 +1,23 @@
 package processor_test
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/processor"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 )
 
+var defaultOptions = domain.FrameOptions{FPS: 1, Format: domain.ImageFormatPNG}
+
 func TestFFmpegProcessor_Process(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -21,30 +29,66 @@ func TestFFmpegProcessor_Process(t *testing.T) {
 		}
 
 		processor := processor.NewFFmpegProcessor()
-		fullPath, fileName, err := processor.Process(context.Background(), videoPath)
+		var output bytes.Buffer
+		archive, err := processor.Process(context.Background(), videoPath, defaultOptions, &output)
 		if err != nil {
 			t.Fatalf("Process failed: %v", err)
 		}
-		if fullPath == "" || fileName == "" {
-			t.Errorf("Expected non-empty fullPath and fileName, got '%s', '%s'", fullPath, fileName)
+		if archive.FrameCount == 0 || archive.SizeBytes == 0 {
+			t.Errorf("Expected frames in a non-empty archive, got %d frames and %d bytes", archive.FrameCount, archive.SizeBytes)
+		}
+		if archive.SizeBytes != int64(output.Len()) {
+			t.Errorf("Expected archive size %d to match the bytes written, got %d", output.Len(), archive.SizeBytes)
 		}
-		if !filepath.IsAbs(fullPath) {
-			t.Errorf("Expected absolute path for zip, got: %s", fullPath)
+
+		zipReader, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+		if err != nil {
+			t.Fatalf("Expected a finalized zip archive: %v", err)
 		}
-		if filepath.Ext(fileName) != ".zip" {
-			t.Errorf("Expected .zip file, got: %s", fileName)
+		if len(zipReader.File) != archive.FrameCount {
+			t.Errorf("Expected %d entries in the archive, got %d", archive.FrameCount, len(zipReader.File))
+		}
+	})
+
+	t.Run("ScaledJPGFramesFromPartOfTheVideo", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		videoPath := filepath.Join(tmpDir, "test.mp4")
+
+		cmd := exec.Command("ffmpeg", "-f", "lavfi", "-i", "color=c=black:s=320x240:d=4", videoPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to create dummy video: %v, output: %s", err, output)
+		}
+
+		processor := processor.NewFFmpegProcessor()
+		archive, err := processor.Process(context.Background(), videoPath, domain.FrameOptions{
+			IntervalSeconds: 0.5,
+			Format:          domain.ImageFormatJPG,
+			Quality:         50,
+			MaxWidth:        160,
+			StartSeconds:    1,
+			EndSeconds:      3,
+		}, io.Discard)
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if archive.FrameCount == 0 {
+			t.Errorf("Expected JPG frames to be extracted, got none")
 		}
 	})
 
 	t.Run("InvalidVideo", func(t *testing.T) {
 		processor := processor.NewFFmpegProcessor()
-		_, _, err := processor.Process(context.Background(), "nonexistent.mp4")
+		_, err := processor.Process(context.Background(), "nonexistent.mp4", defaultOptions, io.Discard)
 		if err == nil {
 			t.Fatal("Expected error for nonexistent video file, got nil")
 		}
 		if !strings.Contains(err.Error(), "ffmpeg execution error") {
 			t.Errorf("Expected ffmpeg execution error, got: %v", err)
 		}
+		var stageErr *domain.StageError
+		if !errors.As(err, &stageErr) || stageErr.Stage != domain.StageExtract {
+			t.Errorf("Expected an error of the extract stage, got: %#v", err)
+		}
 	})
 
 	t.Run("ContextCancel", func(t *testing.T) {
@@ -59,9 +103,70 @@ func TestFFmpegProcessor_Process(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel()
 
-		_, _, err := processor.Process(ctx, videoPath)
+		_, err := processor.Process(ctx, videoPath, defaultOptions, io.Discard)
 		if err == nil {
 			t.Fatal("Expected error due to context cancellation, got nil")
 		}
 	})
 }
+
+func TestFFmpegProcessor_ProbeVideo(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		videoPath := filepath.Join(tmpDir, "test.mp4")
+
+		cmd := exec.Command("ffmpeg", "-f", "lavfi", "-i", "color=c=black:s=320x240:d=2:r=25", videoPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to create dummy video: %v, output: %s", err, output)
+		}
+
+		processor := processor.NewFFmpegProcessor()
+		metadata, err := processor.ProbeVideo(context.Background(), videoPath)
+		if err != nil {
+			t.Fatalf("ProbeVideo failed: %v", err)
+		}
+		if metadata.Width != 320 || metadata.Height != 240 {
+			t.Errorf("Expected 320x240, got %dx%d", metadata.Width, metadata.Height)
+		}
+		if metadata.FrameRate != 25 {
+			t.Errorf("Expected 25 fps, got %v", metadata.FrameRate)
+		}
+		if metadata.DurationSeconds <= 0 || metadata.SizeBytes <= 0 || metadata.VideoCodec == "" {
+			t.Errorf("Expected duration, size and codec to be set, got %+v", metadata)
+		}
+	})
+
+	t.Run("StillImage", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		imagePath := filepath.Join(tmpDir, "foto.jpg")
+
+		cmd := exec.Command("ffmpeg", "-f", "lavfi", "-i", "color=c=black:s=320x240", "-frames:v", "1", imagePath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to create dummy image: %v, output: %s", err, output)
+		}
+
+		processor := processor.NewFFmpegProcessor()
+		_, err := processor.ProbeVideo(context.Background(), imagePath)
+		if !errors.Is(err, domain.ErrInvalidVideo) {
+			t.Errorf("Expected invalid video error, got: %v", err)
+		}
+	})
+
+	t.Run("NotAMediaFile", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		textPath := filepath.Join(tmpDir, "notes.mp4")
+		if err := os.WriteFile(textPath, []byte("not a video"), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+
+		processor := processor.NewFFmpegProcessor()
+		_, err := processor.ProbeVideo(context.Background(), textPath)
+		if !errors.Is(err, domain.ErrInvalidVideo) {
+			t.Errorf("Expected invalid video error, got: %v", err)
+		}
+		var stageErr *domain.StageError
+		if !errors.As(err, &stageErr) || stageErr.Stderr == "" {
+			t.Errorf("Expected the ffprobe output to be kept, got: %#v", err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type JetStreamAdapter struct {
+	consumer     ports.JetStreamConsumer
+	publisher    ports.JetStreamPublisher
+	errorSubject string
+}
+
+func NewJetStreamAdapter(consumer ports.JetStreamConsumer, publisher ports.JetStreamPublisher, errorSubject string) *JetStreamAdapter {
+	return &JetStreamAdapter{
+		consumer:     consumer,
+		publisher:    publisher,
+		errorSubject: errorSubject,
+	}
+}
+
+func (j *JetStreamAdapter) Publish(ctx context.Context, event model.JobErrorEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize error message to JSON: %w", err)
+	}
+
+	// Lets whoever consumes the error subject continue the job's trace.
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	msg := nats.NewMsg(j.errorSubject)
+	msg.Data = body
+	for key, value := range carrier {
+		msg.Header.Set(key, value)
+	}
+
+	if _, err := j.publisher.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish message to JetStream: %w", err)
+	}
+
+	return nil
+}
+
+// Receive waits up to wait for a first message and then takes whatever else
+// the stream already holds for the consumer, without blocking again. A pull
+// request only returns once its whole batch arrived, hence the two fetches.
+func (j *JetStreamAdapter) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]model.Message, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	batch, err := j.consumer.Fetch(1, jetstream.FetchContext(fetchCtx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive messages from JetStream: %w", err)
+	}
+	messages, err := j.collect(batch)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to receive messages from JetStream: %w", err)
+		}
+	}
+	if len(messages) == 0 || maxMessages <= 1 {
+		return messages, nil
+	}
+
+	// The first message is already taken, so a failure here only leaves the
+	// rest for the next receive.
+	batch, err = j.consumer.FetchNoWait(maxMessages - 1)
+	if err != nil {
+		return messages, nil
+	}
+	more, _ := j.collect(batch)
+	return append(messages, more...), nil
+}
+
+func (j *JetStreamAdapter) collect(batch jetstream.MessageBatch) ([]model.Message, error) {
+	var messages []model.Message
+	for msg := range batch.Messages() {
+		messages = append(messages, toJetStreamMessage(msg))
+	}
+	return messages, batch.Error()
+}
+
+func toJetStreamMessage(msg jetstream.Msg) model.Message {
+	message := model.Message{
+		Body:    msg.Data(),
+		Attempt: 1,
+		Acker:   &jetStreamAcker{msg: msg},
+	}
+
+	attributes := make(map[string]string, len(msg.Headers()))
+	for key, values := range msg.Headers() {
+		if len(values) > 0 {
+			attributes[key] = values[0]
+		}
+	}
+	message.Attributes = attributes
+
+	if meta, err := msg.Metadata(); err == nil {
+		message.ID = strconv.FormatUint(meta.Sequence.Stream, 10)
+		if meta.NumDelivered > 0 {
+			message.Attempt = int(meta.NumDelivered)
+		}
+	}
+	return message
+}
+
+// Ping checks that the durable consumer exists and can be reached.
+func (j *JetStreamAdapter) Ping(ctx context.Context) error {
+	if _, err := j.consumer.Info(ctx); err != nil {
+		return fmt.Errorf("failed to get JetStream consumer info: %w", err)
+	}
+	return nil
+}
+
+// jetStreamAcker settles a message through its reply subject. Extending
+// restarts the consumer's ack wait rather than setting a new one.
+type jetStreamAcker struct {
+	msg jetstream.Msg
+}
+
+func (a *jetStreamAcker) Ack(_ context.Context) error {
+	if err := a.msg.Ack(); err != nil {
+		return fmt.Errorf("failed to ack message in JetStream: %w", err)
+	}
+	return nil
+}
+
+func (a *jetStreamAcker) Nack(_ context.Context, delay time.Duration) error {
+	if err := a.msg.NakWithDelay(delay); err != nil {
+		return fmt.Errorf("failed to nak message in JetStream: %w", err)
+	}
+	return nil
+}
+
+func (a *jetStreamAcker) Extend(_ context.Context, _ time.Duration) error {
+	if err := a.msg.InProgress(); err != nil {
+		return fmt.Errorf("failed to extend message in JetStream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/queue"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeJetStreamMsg is a received message that records how it was settled.
+type fakeJetStreamMsg struct {
+	jetstream.Msg
+
+	data       []byte
+	headers    nats.Header
+	meta       *jetstream.MsgMetadata
+	acked      bool
+	nakDelay   time.Duration
+	inProgress int
+}
+
+func (m *fakeJetStreamMsg) Data() []byte         { return m.data }
+func (m *fakeJetStreamMsg) Headers() nats.Header { return m.headers }
+
+func (m *fakeJetStreamMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return m.meta, nil
+}
+
+func (m *fakeJetStreamMsg) Ack() error {
+	m.acked = true
+	return nil
+}
+
+func (m *fakeJetStreamMsg) NakWithDelay(delay time.Duration) error {
+	m.nakDelay = delay
+	return nil
+}
+
+func (m *fakeJetStreamMsg) InProgress() error {
+	m.inProgress++
+	return nil
+}
+
+// fakeMessageBatch is a batch that already received all of its messages.
+type fakeMessageBatch struct {
+	jetstream.MessageBatch
+
+	msgs chan jetstream.Msg
+	err  error
+}
+
+func newFakeMessageBatch(err error, msgs ...jetstream.Msg) *fakeMessageBatch {
+	batch := &fakeMessageBatch{msgs: make(chan jetstream.Msg, len(msgs)), err: err}
+	for _, msg := range msgs {
+		batch.msgs <- msg
+	}
+	close(batch.msgs)
+	return batch
+}
+
+func (b *fakeMessageBatch) Messages() <-chan jetstream.Msg { return b.msgs }
+func (b *fakeMessageBatch) Error() error                   { return b.err }
+
+func newFakeJetStreamMsg(sequence, delivered uint64) *fakeJetStreamMsg {
+	return &fakeJetStreamMsg{
+		data:    []byte(`{"job_id":"job-123"}`),
+		headers: nats.Header{"traceparent": []string{"00-abc-01"}},
+		meta: &jetstream.MsgMetadata{
+			Sequence:     jetstream.SequencePair{Stream: sequence},
+			NumDelivered: delivered,
+		},
+	}
+}
+
+type jetStreamHandleTest struct {
+	suite.Suite
+
+	ctx           context.Context
+	consumerMock  *mocks.MockJetStreamConsumer
+	publisherMock *mocks.MockJetStreamPublisher
+	adapter       *queue.JetStreamAdapter
+}
+
+func (s *jetStreamHandleTest) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.ctx = context.Background()
+	s.consumerMock = mocks.NewMockJetStreamConsumer(ctrl)
+	s.publisherMock = mocks.NewMockJetStreamPublisher(ctrl)
+	s.adapter = queue.NewJetStreamAdapter(s.consumerMock, s.publisherMock, "jobs.error")
+}
+
+func Test_JetStreamHandleTest(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(jetStreamHandleTest))
+}
+
+func (s *jetStreamHandleTest) Test_Publish() {
+	st := s.T()
+	event := model.JobErrorEvent{JobID: "job-123"}
+
+	st.Run("should publish the event to the error subject", func(t *testing.T) {
+		expectedBody, err := json.Marshal(event)
+		s.NoError(err)
+
+		s.publisherMock.EXPECT().
+			PublishMsg(s.ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, msg *nats.Msg, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+				s.Equal("jobs.error", msg.Subject)
+				s.Equal(expectedBody, msg.Data)
+				return &jetstream.PubAck{}, nil
+			})
+
+		s.NoError(s.adapter.Publish(s.ctx, event))
+	})
+
+	st.Run("should return error if the publish fails", func(t *testing.T) {
+		s.publisherMock.EXPECT().
+			PublishMsg(s.ctx, gomock.Any()).
+			Return(nil, nats.ErrNoResponders)
+
+		s.ErrorIs(s.adapter.Publish(s.ctx, event), nats.ErrNoResponders)
+	})
+}
+
+func (s *jetStreamHandleTest) Test_Receive() {
+	st := s.T()
+
+	st.Run("should take the rest of the batch without waiting", func(t *testing.T) {
+		s.consumerMock.EXPECT().
+			Fetch(1, gomock.Any()).
+			Return(newFakeMessageBatch(nil, newFakeJetStreamMsg(41, 1)), nil)
+		s.consumerMock.EXPECT().
+			FetchNoWait(9).
+			Return(newFakeMessageBatch(nil, newFakeJetStreamMsg(42, 3)), nil)
+
+		msgs, err := s.adapter.Receive(s.ctx, 10, time.Second)
+		s.NoError(err)
+		s.Require().Len(msgs, 2)
+		s.Equal("41", msgs[0].ID)
+		s.Equal([]byte(`{"job_id":"job-123"}`), msgs[0].Body)
+		s.Equal("00-abc-01", msgs[0].Attributes["traceparent"])
+		s.Equal(1, msgs[0].Attempt)
+		s.Equal(3, msgs[1].Attempt)
+	})
+
+	st.Run("should return no messages once the wait elapses", func(t *testing.T) {
+		s.consumerMock.EXPECT().
+			Fetch(1, gomock.Any()).
+			Return(newFakeMessageBatch(context.DeadlineExceeded), nil)
+
+		msgs, err := s.adapter.Receive(s.ctx, 10, time.Second)
+		s.NoError(err)
+		s.Empty(msgs)
+	})
+
+	st.Run("should return error when the fetch fails", func(t *testing.T) {
+		s.consumerMock.EXPECT().
+			Fetch(1, gomock.Any()).
+			Return(newFakeMessageBatch(jetstream.ErrNoHeartbeat), nil)
+
+		_, err := s.adapter.Receive(s.ctx, 10, time.Second)
+		s.ErrorIs(err, jetstream.ErrNoHeartbeat)
+	})
+}
+
+func (s *jetStreamHandleTest) Test_Settle() {
+	st := s.T()
+
+	receive := func(msg *fakeJetStreamMsg) model.Message {
+		s.consumerMock.EXPECT().
+			Fetch(1, gomock.Any()).
+			Return(newFakeMessageBatch(nil, msg), nil)
+
+		msgs, err := s.adapter.Receive(s.ctx, 1, time.Second)
+		s.Require().NoError(err)
+		s.Require().Len(msgs, 1)
+		return msgs[0]
+	}
+
+	st.Run("should ack, nak with delay and mark in progress", func(t *testing.T) {
+		raw := newFakeJetStreamMsg(7, 1)
+		msg := receive(raw)
+
+		s.NoError(msg.Extend(s.ctx, time.Minute))
+		s.NoError(msg.Nack(s.ctx, 30*time.Second))
+		s.NoError(msg.Ack(s.ctx))
+
+		s.Equal(1, raw.inProgress)
+		s.Equal(30*time.Second, raw.nakDelay)
+		s.True(raw.acked)
+	})
+}
+
+func (s *jetStreamHandleTest) Test_Ping() {
+	st := s.T()
+
+	st.Run("should pass when the consumer exists", func(t *testing.T) {
+		s.consumerMock.EXPECT().Info(s.ctx).Return(&jetstream.ConsumerInfo{}, nil)
+		s.NoError(s.adapter.Ping(s.ctx))
+	})
+
+	st.Run("should fail when the consumer can't be found", func(t *testing.T) {
+		s.consumerMock.EXPECT().Info(s.ctx).Return(nil, jetstream.ErrConsumerNotFound)
+		s.ErrorIs(s.adapter.Ping(s.ctx), jetstream.ErrConsumerNotFound)
+	})
+}
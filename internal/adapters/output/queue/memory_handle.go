@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// MemoryQueue is a work queue held in memory, for running the worker without
+// a broker. It behaves like SQS: a received message stays hidden for the
+// visibility timeout and is delivered again unless it is acked first.
+type MemoryQueue struct {
+	mu                sync.Mutex
+	messages          []*memoryMessage
+	errors            []model.JobErrorEvent
+	lastID            int
+	visibilityTimeout time.Duration
+	// notify wakes up a waiting Receive when a message is sent.
+	notify chan struct{}
+}
+
+type memoryMessage struct {
+	id         string
+	body       []byte
+	attributes map[string]string
+	deliveries int
+	visibleAt  time.Time
+}
+
+func NewMemoryQueue(visibilityTimeout time.Duration) *MemoryQueue {
+	return &MemoryQueue{
+		visibilityTimeout: visibilityTimeout,
+		notify:            make(chan struct{}, 1),
+	}
+}
+
+// Send puts a message on the work queue and returns its ID.
+func (q *MemoryQueue) Send(body []byte, attributes map[string]string) string {
+	q.mu.Lock()
+	q.lastID++
+	id := strconv.Itoa(q.lastID)
+	q.messages = append(q.messages, &memoryMessage{
+		id:         id,
+		body:       body,
+		attributes: attributes,
+		visibleAt:  time.Now(),
+	})
+	q.mu.Unlock()
+
+	q.wake()
+	return id
+}
+
+// Len returns how many messages were sent and not acked yet.
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// Errors returns the events published to the error queue so far.
+func (q *MemoryQueue) Errors() []model.JobErrorEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.errors)
+}
+
+func (q *MemoryQueue) Publish(_ context.Context, event model.JobErrorEvent) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.errors = append(q.errors, event)
+	return nil
+}
+
+// Receive waits up to wait for a visible message and returns every visible
+// message, up to maxMessages.
+func (q *MemoryQueue) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]model.Message, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		messages, nextVisible := q.take(maxMessages)
+		if len(messages) > 0 {
+			return messages, nil
+		}
+
+		sleep := time.Until(deadline)
+		if sleep <= 0 {
+			return nil, nil
+		}
+		if !nextVisible.IsZero() {
+			sleep = min(sleep, time.Until(nextVisible))
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-q.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// take hides and returns the visible messages. When there are none, it also
+// returns when the next hidden message becomes visible, or the zero time.
+func (q *MemoryQueue) take(maxMessages int) ([]model.Message, time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	var messages []model.Message
+	var nextVisible time.Time
+	for _, msg := range q.messages {
+		if msg.visibleAt.After(now) {
+			if nextVisible.IsZero() || msg.visibleAt.Before(nextVisible) {
+				nextVisible = msg.visibleAt
+			}
+			continue
+		}
+		if len(messages) == maxMessages {
+			break
+		}
+		msg.deliveries++
+		msg.visibleAt = now.Add(q.visibilityTimeout)
+		messages = append(messages, model.Message{
+			ID:         msg.id,
+			Body:       msg.body,
+			Attributes: msg.attributes,
+			Attempt:    msg.deliveries,
+			Acker:      &memoryAcker{queue: q, id: msg.id},
+		})
+	}
+	return messages, nextVisible
+}
+
+// Ping always succeeds: the queue lives in the worker's own memory.
+func (q *MemoryQueue) Ping(_ context.Context) error {
+	return nil
+}
+
+func (q *MemoryQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// update applies fn to the message with the given ID, failing when it was
+// already acked.
+func (q *MemoryQueue) update(id string, fn func(i int)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := slices.IndexFunc(q.messages, func(msg *memoryMessage) bool { return msg.id == id })
+	if i < 0 {
+		return fmt.Errorf("message '%s' is no longer in the queue", id)
+	}
+	fn(i)
+	return nil
+}
+
+// memoryAcker settles a message by its ID. Nacking and extending both change
+// when it becomes visible again.
+type memoryAcker struct {
+	queue *MemoryQueue
+	id    string
+}
+
+func (a *memoryAcker) Ack(_ context.Context) error {
+	return a.queue.update(a.id, func(i int) {
+		a.queue.messages = slices.Delete(a.queue.messages, i, i+1)
+	})
+}
+
+func (a *memoryAcker) Nack(_ context.Context, delay time.Duration) error {
+	return a.queue.update(a.id, func(i int) {
+		a.queue.messages[i].visibleAt = time.Now().Add(delay)
+	})
+}
+
+func (a *memoryAcker) Extend(_ context.Context, timeout time.Duration) error {
+	return a.queue.update(a.id, func(i int) {
+		a.queue.messages[i].visibleAt = time.Now().Add(timeout)
+	})
+}
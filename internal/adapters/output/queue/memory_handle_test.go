@@ -0,0 +1,133 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/queue"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MemoryQueue_Receive(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("should deliver sent messages up to the limit", func(t *testing.T) {
+		q := queue.NewMemoryQueue(time.Minute)
+		q.Send([]byte(`{"job_id":"job-1"}`), map[string]string{"traceparent": "00-abc-01"})
+		q.Send([]byte(`{"job_id":"job-2"}`), nil)
+		q.Send([]byte(`{"job_id":"job-3"}`), nil)
+
+		msgs, err := q.Receive(ctx, 2, time.Second)
+		require.NoError(t, err)
+		require.Len(t, msgs, 2)
+		assert.Equal(t, []byte(`{"job_id":"job-1"}`), msgs[0].Body)
+		assert.Equal(t, "00-abc-01", msgs[0].Attributes["traceparent"])
+		assert.Equal(t, 1, msgs[0].Attempt)
+
+		rest, err := q.Receive(ctx, 10, time.Second)
+		require.NoError(t, err)
+		assert.Len(t, rest, 1)
+	})
+
+	t.Run("should wake up when a message is sent while waiting", func(t *testing.T) {
+		q := queue.NewMemoryQueue(time.Minute)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			q.Send([]byte("late"), nil)
+		}()
+
+		msgs, err := q.Receive(ctx, 10, time.Second)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, []byte("late"), msgs[0].Body)
+	})
+
+	t.Run("should return no messages once the wait elapses", func(t *testing.T) {
+		q := queue.NewMemoryQueue(time.Minute)
+
+		msgs, err := q.Receive(ctx, 10, 10*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("should return the context error when cancelled", func(t *testing.T) {
+		q := queue.NewMemoryQueue(time.Minute)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := q.Receive(cancelled, 10, time.Second)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should redeliver a message after its visibility timeout", func(t *testing.T) {
+		q := queue.NewMemoryQueue(20 * time.Millisecond)
+		q.Send([]byte("body"), nil)
+
+		first, err := q.Receive(ctx, 1, time.Second)
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+
+		again, err := q.Receive(ctx, 1, time.Second)
+		require.NoError(t, err)
+		require.Len(t, again, 1)
+		assert.Equal(t, first[0].ID, again[0].ID)
+		assert.Equal(t, 2, again[0].Attempt)
+	})
+}
+
+func Test_MemoryQueue_Settle(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	receiveOne := func(t *testing.T, q *queue.MemoryQueue, wait time.Duration) []model.Message {
+		msgs, err := q.Receive(ctx, 1, wait)
+		require.NoError(t, err)
+		return msgs
+	}
+
+	t.Run("should remove an acked message", func(t *testing.T) {
+		q := queue.NewMemoryQueue(10 * time.Millisecond)
+		q.Send([]byte("body"), nil)
+		msg := receiveOne(t, q, time.Second)[0]
+
+		require.NoError(t, msg.Ack(ctx))
+		assert.Zero(t, q.Len())
+		assert.Error(t, msg.Ack(ctx), "an acked message can't be settled again")
+	})
+
+	t.Run("should hide a nacked message for the delay", func(t *testing.T) {
+		q := queue.NewMemoryQueue(time.Millisecond)
+		q.Send([]byte("body"), nil)
+		msg := receiveOne(t, q, time.Second)[0]
+
+		require.NoError(t, msg.Nack(ctx, time.Hour))
+		assert.Empty(t, receiveOne(t, q, 20*time.Millisecond))
+
+		require.NoError(t, msg.Nack(ctx, 0))
+		assert.Len(t, receiveOne(t, q, time.Second), 1)
+	})
+
+	t.Run("should keep an extended message hidden", func(t *testing.T) {
+		q := queue.NewMemoryQueue(10 * time.Millisecond)
+		q.Send([]byte("body"), nil)
+		msg := receiveOne(t, q, time.Second)[0]
+
+		require.NoError(t, msg.Extend(ctx, time.Hour))
+		assert.Empty(t, receiveOne(t, q, 30*time.Millisecond))
+		assert.Equal(t, 1, q.Len())
+	})
+}
+
+func Test_MemoryQueue_Publish(t *testing.T) {
+	t.Parallel()
+	q := queue.NewMemoryQueue(time.Minute)
+
+	require.NoError(t, q.Publish(context.Background(), model.JobErrorEvent{JobID: "job-123"}))
+
+	assert.Equal(t, []model.JobErrorEvent{{JobID: "job-123"}}, q.Errors())
+	assert.NoError(t, q.Ping(context.Background()))
+}
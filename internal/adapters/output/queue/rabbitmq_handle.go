@@ -0,0 +1,206 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// attemptHeader counts the deliveries of a message that went through the
+// retry queue, since RabbitMQ only flags redeliveries of the same copy.
+const attemptHeader = "x-delivery-attempt"
+
+type RabbitMQAdapter struct {
+	channel    ports.AMQPChannel
+	retryQueue string
+	errorQueue string
+	deliveries <-chan amqp.Delivery
+}
+
+// NewRabbitMQAdapter starts consuming the work queue, taking at most prefetch
+// unacknowledged messages at a time. It also declares the retry queue that
+// delayed redeliveries wait in: messages expire from it back to the work
+// queue.
+func NewRabbitMQAdapter(channel ports.AMQPChannel, workQueue, errorQueue string, prefetch int) (*RabbitMQAdapter, error) {
+	if err := channel.Qos(prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set RabbitMQ prefetch: %w", err)
+	}
+
+	retryQueue := workQueue + ".retry"
+	_, err := channel.QueueDeclare(retryQueue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": workQueue,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare retry queue '%s': %w", retryQueue, err)
+	}
+
+	deliveries, err := channel.Consume(workQueue, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume queue '%s': %w", workQueue, err)
+	}
+
+	return &RabbitMQAdapter{
+		channel:    channel,
+		retryQueue: retryQueue,
+		errorQueue: errorQueue,
+		deliveries: deliveries,
+	}, nil
+}
+
+func (r *RabbitMQAdapter) Publish(ctx context.Context, event model.JobErrorEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize error message to JSON: %w", err)
+	}
+
+	// Lets whoever consumes the error queue continue the job's trace.
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	headers := make(amqp.Table, len(carrier))
+	for key, value := range carrier {
+		headers[key] = value
+	}
+
+	err = r.channel.PublishWithContext(ctx, "", r.errorQueue, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Headers:      headers,
+		Body:         body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message to RabbitMQ: %w", err)
+	}
+
+	return nil
+}
+
+// Receive waits up to wait for a first delivery and then takes whatever else
+// the broker already pushed, without blocking again.
+func (r *RabbitMQAdapter) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]model.Message, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var messages []model.Message
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return messages, nil
+	case d, ok := <-r.deliveries:
+		if !ok {
+			return nil, errors.New("RabbitMQ delivery channel closed")
+		}
+		messages = append(messages, r.toMessage(d))
+	}
+
+	for len(messages) < maxMessages {
+		select {
+		case d, ok := <-r.deliveries:
+			if !ok {
+				return messages, nil
+			}
+			messages = append(messages, r.toMessage(d))
+		default:
+			return messages, nil
+		}
+	}
+	return messages, nil
+}
+
+func (r *RabbitMQAdapter) toMessage(d amqp.Delivery) model.Message {
+	id := d.MessageId
+	if id == "" {
+		id = strconv.FormatUint(d.DeliveryTag, 10)
+	}
+
+	attributes := make(map[string]string, len(d.Headers))
+	for key, value := range d.Headers {
+		if s, ok := value.(string); ok {
+			attributes[key] = s
+		}
+	}
+
+	attempt := 1
+	if previous, ok := d.Headers[attemptHeader].(int32); ok && previous > 0 {
+		attempt = int(previous)
+	}
+	if d.Redelivered {
+		attempt++
+	}
+
+	return model.Message{
+		ID:         id,
+		Body:       d.Body,
+		Attributes: attributes,
+		Attempt:    attempt,
+		Acker:      &rabbitMQAcker{adapter: r, delivery: d, attempt: attempt},
+	}
+}
+
+// Ping checks that the channel to the broker is still open.
+func (r *RabbitMQAdapter) Ping(_ context.Context) error {
+	if r.channel.IsClosed() {
+		return errors.New("RabbitMQ channel is closed")
+	}
+	return nil
+}
+
+// rabbitMQAcker settles a delivery on the channel it came from. RabbitMQ has
+// no per-message delay, so a nack republishes the message to the retry queue
+// with an expiration of delay.
+type rabbitMQAcker struct {
+	adapter  *RabbitMQAdapter
+	delivery amqp.Delivery
+	attempt  int
+}
+
+func (a *rabbitMQAcker) Ack(_ context.Context) error {
+	if err := a.delivery.Ack(false); err != nil {
+		return fmt.Errorf("failed to ack message in RabbitMQ: %w", err)
+	}
+	return nil
+}
+
+func (a *rabbitMQAcker) Nack(ctx context.Context, delay time.Duration) error {
+	headers := make(amqp.Table, len(a.delivery.Headers)+1)
+	for key, value := range a.delivery.Headers {
+		headers[key] = value
+	}
+	headers[attemptHeader] = int32(a.attempt + 1)
+
+	err := a.adapter.channel.PublishWithContext(ctx, "", a.adapter.retryQueue, false, false, amqp.Publishing{
+		ContentType:  a.delivery.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    a.delivery.MessageId,
+		Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		Headers:      headers,
+		Body:         a.delivery.Body,
+	})
+	if err != nil {
+		// Without the copy the message must not be lost, so it goes straight
+		// back to the work queue instead.
+		_ = a.delivery.Nack(false, true)
+		return fmt.Errorf("failed to schedule retry in RabbitMQ: %w", err)
+	}
+
+	if err := a.delivery.Ack(false); err != nil {
+		return fmt.Errorf("failed to ack message in RabbitMQ: %w", err)
+	}
+	return nil
+}
+
+// Extend is a no-op: RabbitMQ keeps an unacknowledged delivery with its
+// consumer until the channel closes or the broker's consumer_timeout expires.
+func (a *rabbitMQAcker) Extend(_ context.Context, _ time.Duration) error {
+	return nil
+}
@@ -0,0 +1,246 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/queue"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeAcknowledger records how a delivery was settled.
+type fakeAcknowledger struct {
+	acked   []uint64
+	nacked  []uint64
+	requeue bool
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, _ bool) error {
+	f.acked = append(f.acked, tag)
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, _ bool, requeue bool) error {
+	f.nacked = append(f.nacked, tag)
+	f.requeue = requeue
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return f.Nack(tag, false, requeue)
+}
+
+type rabbitMQHandleTest struct {
+	suite.Suite
+
+	ctx         context.Context
+	channelMock *mocks.MockAMQPChannel
+	deliveries  chan amqp.Delivery
+	adapter     *queue.RabbitMQAdapter
+}
+
+func (s *rabbitMQHandleTest) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.ctx = context.Background()
+	s.channelMock = mocks.NewMockAMQPChannel(ctrl)
+	s.deliveries = make(chan amqp.Delivery, 10)
+
+	s.channelMock.EXPECT().Qos(4, 0, false).Return(nil)
+	s.channelMock.EXPECT().
+		QueueDeclare("work-queue.retry", true, false, false, false, amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": "work-queue",
+		}).
+		Return(amqp.Queue{}, nil)
+	s.channelMock.EXPECT().
+		Consume("work-queue", "", false, false, false, false, nil).
+		Return((<-chan amqp.Delivery)(s.deliveries), nil)
+
+	adapter, err := queue.NewRabbitMQAdapter(s.channelMock, "work-queue", "error-queue", 4)
+	s.Require().NoError(err)
+	s.adapter = adapter
+}
+
+func Test_RabbitMQHandleTest(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(rabbitMQHandleTest))
+}
+
+func (s *rabbitMQHandleTest) Test_NewRabbitMQAdapter() {
+	st := s.T()
+
+	st.Run("should return error when the work queue can't be consumed", func(t *testing.T) {
+		channel := mocks.NewMockAMQPChannel(gomock.NewController(t))
+		channel.EXPECT().Qos(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		channel.EXPECT().QueueDeclare(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(amqp.Queue{}, nil)
+		channel.EXPECT().
+			Consume("work-queue", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("NOT_FOUND"))
+
+		_, err := queue.NewRabbitMQAdapter(channel, "work-queue", "error-queue", 1)
+		s.ErrorContains(err, "failed to consume queue 'work-queue'")
+	})
+}
+
+func (s *rabbitMQHandleTest) Test_Publish() {
+	st := s.T()
+	event := model.JobErrorEvent{JobID: "job-123"}
+
+	st.Run("should publish a persistent message to the error queue", func(t *testing.T) {
+		expectedBody, err := json.Marshal(event)
+		s.NoError(err)
+
+		s.channelMock.EXPECT().
+			PublishWithContext(s.ctx, "", "error-queue", false, false, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, _, _ bool, msg amqp.Publishing) error {
+				s.Equal(expectedBody, msg.Body)
+				s.Equal(amqp.Persistent, msg.DeliveryMode)
+				s.Equal("application/json", msg.ContentType)
+				return nil
+			})
+
+		s.NoError(s.adapter.Publish(s.ctx, event))
+	})
+
+	st.Run("should return error if the publish fails", func(t *testing.T) {
+		s.channelMock.EXPECT().
+			PublishWithContext(s.ctx, "", "error-queue", false, false, gomock.Any()).
+			Return(amqp.ErrClosed)
+
+		err := s.adapter.Publish(s.ctx, event)
+		s.ErrorIs(err, amqp.ErrClosed)
+	})
+}
+
+func (s *rabbitMQHandleTest) Test_Receive() {
+	st := s.T()
+
+	st.Run("should take deliveries already pushed up to the limit", func(t *testing.T) {
+		acker := &fakeAcknowledger{}
+		for tag := uint64(1); tag <= 3; tag++ {
+			s.deliveries <- amqp.Delivery{Acknowledger: acker, DeliveryTag: tag, Body: []byte(`{"job_id":"job-123"}`)}
+		}
+
+		msgs, err := s.adapter.Receive(s.ctx, 2, time.Second)
+		s.NoError(err)
+		s.Len(msgs, 2)
+		s.Equal("1", msgs[0].ID)
+		s.Equal([]byte(`{"job_id":"job-123"}`), msgs[0].Body)
+
+		rest, err := s.adapter.Receive(s.ctx, 10, time.Second)
+		s.NoError(err)
+		s.Len(rest, 1)
+	})
+
+	st.Run("should return no messages once the wait elapses", func(t *testing.T) {
+		msgs, err := s.adapter.Receive(s.ctx, 10, 10*time.Millisecond)
+		s.NoError(err)
+		s.Empty(msgs)
+	})
+
+	st.Run("should count attempts across retries and redeliveries", func(t *testing.T) {
+		acker := &fakeAcknowledger{}
+		s.deliveries <- amqp.Delivery{
+			Acknowledger: acker,
+			MessageId:    "msg-1",
+			Redelivered:  true,
+			Headers:      amqp.Table{"x-delivery-attempt": int32(2), "traceparent": "00-abc-01"},
+		}
+
+		msgs, err := s.adapter.Receive(s.ctx, 1, time.Second)
+		s.NoError(err)
+		s.Require().Len(msgs, 1)
+		s.Equal("msg-1", msgs[0].ID)
+		s.Equal(3, msgs[0].Attempt)
+		s.Equal("00-abc-01", msgs[0].Attributes["traceparent"])
+	})
+
+	st.Run("should return error when the delivery channel is closed", func(t *testing.T) {
+		deliveries := make(chan amqp.Delivery)
+		close(deliveries)
+		channel := mocks.NewMockAMQPChannel(gomock.NewController(t))
+		channel.EXPECT().Qos(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		channel.EXPECT().QueueDeclare(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(amqp.Queue{}, nil)
+		channel.EXPECT().
+			Consume(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return((<-chan amqp.Delivery)(deliveries), nil)
+		adapter, err := queue.NewRabbitMQAdapter(channel, "work-queue", "error-queue", 1)
+		s.Require().NoError(err)
+
+		_, err = adapter.Receive(s.ctx, 1, time.Second)
+		s.ErrorContains(err, "delivery channel closed")
+	})
+}
+
+func (s *rabbitMQHandleTest) Test_Settle() {
+	st := s.T()
+
+	receiveOne := func(acker *fakeAcknowledger) model.Message {
+		s.deliveries <- amqp.Delivery{Acknowledger: acker, DeliveryTag: 7, MessageId: "msg-7", Body: []byte("body")}
+		msgs, err := s.adapter.Receive(s.ctx, 1, time.Second)
+		s.Require().NoError(err)
+		s.Require().Len(msgs, 1)
+		return msgs[0]
+	}
+
+	st.Run("should ack the delivery", func(t *testing.T) {
+		acker := &fakeAcknowledger{}
+		msg := receiveOne(acker)
+
+		s.NoError(msg.Ack(s.ctx))
+		s.Equal([]uint64{7}, acker.acked)
+	})
+
+	st.Run("should republish to the retry queue with the delay as expiration", func(t *testing.T) {
+		acker := &fakeAcknowledger{}
+		msg := receiveOne(acker)
+
+		s.channelMock.EXPECT().
+			PublishWithContext(s.ctx, "", "work-queue.retry", false, false, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, _, _ bool, pub amqp.Publishing) error {
+				s.Equal("30000", pub.Expiration)
+				s.Equal("msg-7", pub.MessageId)
+				s.Equal([]byte("body"), pub.Body)
+				s.Equal(int32(2), pub.Headers["x-delivery-attempt"])
+				return nil
+			})
+
+		s.NoError(msg.Nack(s.ctx, 30*time.Second))
+		s.Equal([]uint64{7}, acker.acked)
+	})
+
+	st.Run("should requeue the delivery when the retry can't be published", func(t *testing.T) {
+		acker := &fakeAcknowledger{}
+		msg := receiveOne(acker)
+
+		s.channelMock.EXPECT().
+			PublishWithContext(s.ctx, "", "work-queue.retry", false, false, gomock.Any()).
+			Return(amqp.ErrClosed)
+
+		s.ErrorIs(msg.Nack(s.ctx, 30*time.Second), amqp.ErrClosed)
+		s.Empty(acker.acked)
+		s.Equal([]uint64{7}, acker.nacked)
+		s.True(acker.requeue)
+	})
+}
+
+func (s *rabbitMQHandleTest) Test_Ping() {
+	st := s.T()
+
+	st.Run("should pass while the channel is open", func(t *testing.T) {
+		s.channelMock.EXPECT().IsClosed().Return(false)
+		s.NoError(s.adapter.Ping(s.ctx))
+	})
+
+	st.Run("should fail once the channel is closed", func(t *testing.T) {
+		s.channelMock.EXPECT().IsClosed().Return(true)
+		s.Error(s.adapter.Ping(s.ctx))
+	})
+}
@@ -4,25 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
 )
 
 type SQSAdapter struct {
-	client        ports.SQSClient
-	queueURL      string
-	errorQueueURL string
+	client            ports.SQSClient
+	queueURL          string
+	errorQueueURL     string
+	visibilityTimeout time.Duration
 }
 
-func NewSQSAdapter(client ports.SQSClient, errorQueueURL, workQueueURL string) *SQSAdapter {
+// NewSQSAdapter receives from workQueueURL, hiding each message for
+// visibilityTimeout rather than the queue's default, so that it stays hidden
+// until the first heartbeat extends it.
+func NewSQSAdapter(client ports.SQSClient, errorQueueURL, workQueueURL string, visibilityTimeout time.Duration) *SQSAdapter {
 	return &SQSAdapter{
-		client:        client,
-		errorQueueURL: errorQueueURL,
-		queueURL:      workQueueURL,
+		client:            client,
+		errorQueueURL:     errorQueueURL,
+		queueURL:          workQueueURL,
+		visibilityTimeout: visibilityTimeout,
 	}
 }
 
@@ -32,9 +41,14 @@ func (s *SQSAdapter) Publish(ctx context.Context, event model.JobErrorEvent) err
 		return fmt.Errorf("failed to serialize error message to JSON: %w", err)
 	}
 
+	// Lets whoever consumes the error queue continue the job's trace.
+	attributes := tracing.SQSCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, attributes)
+
 	_, err = s.client.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    aws.String(s.errorQueueURL),
-		MessageBody: aws.String(string(body)),
+		QueueUrl:          aws.String(s.errorQueueURL),
+		MessageBody:       aws.String(string(body)),
+		MessageAttributes: attributes,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to publish message to SQS: %w", err)
@@ -43,17 +57,67 @@ func (s *SQSAdapter) Publish(ctx context.Context, event model.JobErrorEvent) err
 	return nil
 }
 
-func (s *SQSAdapter) Receive(ctx context.Context, maxMessages int32, waitTimeSeconds int32) ([]types.Message, error) {
+func (s *SQSAdapter) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]model.Message, error) {
 	out, err := s.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
 		QueueUrl:            aws.String(s.queueURL),
-		MaxNumberOfMessages: maxMessages,
-		WaitTimeSeconds:     waitTimeSeconds,
+		MaxNumberOfMessages: int32(maxMessages),
+		WaitTimeSeconds:     int32(wait / time.Second),
+		VisibilityTimeout:   int32(s.visibilityTimeout / time.Second),
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+		},
+		// The upstream API sends the trace context as message attributes.
+		MessageAttributeNames: []string{"All"},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to receive messages from SQS: %w", err)
 	}
 
-	return out.Messages, nil
+	messages := make([]model.Message, 0, len(out.Messages))
+	for _, msg := range out.Messages {
+		attributes := make(map[string]string, len(msg.MessageAttributes))
+		for name, value := range msg.MessageAttributes {
+			if value.StringValue != nil {
+				attributes[name] = *value.StringValue
+			}
+		}
+		messages = append(messages, model.Message{
+			ID:         aws.ToString(msg.MessageId),
+			Body:       []byte(aws.ToString(msg.Body)),
+			Attributes: attributes,
+			Attempt:    receiveCount(msg),
+			Acker:      &sqsAcker{adapter: s, receiptHandle: aws.ToString(msg.ReceiptHandle)},
+		})
+	}
+	return messages, nil
+}
+
+// receiveCount returns how many times SQS has delivered msg, starting at 1.
+func receiveCount(msg types.Message) int {
+	count, err := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// sqsAcker settles a message through its receipt handle. Nacking and extending
+// both change its visibility timeout.
+type sqsAcker struct {
+	adapter       *SQSAdapter
+	receiptHandle string
+}
+
+func (a *sqsAcker) Ack(ctx context.Context) error {
+	return a.adapter.Delete(ctx, a.receiptHandle)
+}
+
+func (a *sqsAcker) Nack(ctx context.Context, delay time.Duration) error {
+	return a.adapter.ChangeVisibility(ctx, a.receiptHandle, int32(delay/time.Second))
+}
+
+func (a *sqsAcker) Extend(ctx context.Context, timeout time.Duration) error {
+	return a.adapter.ChangeVisibility(ctx, a.receiptHandle, int32(timeout/time.Second))
 }
 
 func (s *SQSAdapter) Delete(ctx context.Context, receiptHandle string) error {
@@ -67,3 +131,31 @@ func (s *SQSAdapter) Delete(ctx context.Context, receiptHandle string) error {
 
 	return nil
 }
+
+// Ping checks that the work and error queues exist and can be reached with
+// the worker's credentials.
+func (s *SQSAdapter) Ping(ctx context.Context) error {
+	for _, queueURL := range []string{s.queueURL, s.errorQueueURL} {
+		_, err := s.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+			QueueUrl:       aws.String(queueURL),
+			AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get attributes of queue '%s': %w", queueURL, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQSAdapter) ChangeVisibility(ctx context.Context, receiptHandle string, timeoutSeconds int32) error {
+	_, err := s.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.queueURL),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: timeoutSeconds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change message visibility in SQS: %w", err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -13,6 +14,9 @@ import (
 	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
 )
 
@@ -28,7 +32,7 @@ func (s *sqsHandleTest) SetupTest() {
 	ctrl := gomock.NewController(s.T())
 	s.ctx = context.Background()
 	s.sqsClientMock = mocks.NewMockSQSClient(ctrl)
-	s.sqsAdapter = *queue.NewSQSAdapter(s.sqsClientMock, "errorQueueURL", "workQueueURL")
+	s.sqsAdapter = *queue.NewSQSAdapter(s.sqsClientMock, "errorQueueURL", "workQueueURL", 2*time.Minute)
 }
 
 func Test_SQSHandleTest(t *testing.T) {
@@ -58,6 +62,30 @@ func (s *sqsHandleTest) Test_Publish() {
 		s.NoError(err)
 	})
 
+	st.Run("should send the trace context as message attributes", func(t *testing.T) {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(s.ctx, trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+
+		s.sqsClientMock.EXPECT().
+			SendMessage(ctx, gomock.AssignableToTypeOf(&sqs.SendMessageInput{})).
+			DoAndReturn(func(ctx context.Context, input *sqs.SendMessageInput, opts ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				attr, ok := input.MessageAttributes["traceparent"]
+				s.Require().True(ok, "expected a traceparent attribute")
+				s.Equal("String", *attr.DataType)
+				s.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", *attr.StringValue)
+				return &sqs.SendMessageOutput{}, nil
+			})
+
+		err := s.sqsAdapter.Publish(ctx, event)
+		s.NoError(err)
+	})
+
 	st.Run("should return error if SendMessage fails", func(t *testing.T) {
 
 		s.sqsClientMock.EXPECT().
@@ -84,26 +112,81 @@ func (s *sqsHandleTest) Test_Receive() {
 				MessageId:     aws.String("msg-2"),
 				ReceiptHandle: aws.String("handle-2"),
 				Body:          aws.String("body-2"),
+				Attributes: map[string]string{
+					string(types.MessageSystemAttributeNameApproximateReceiveCount): "3",
+				},
+				MessageAttributes: map[string]types.MessageAttributeValue{
+					"traceparent": {DataType: aws.String("String"), StringValue: aws.String("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")},
+				},
 			},
 		}
 
 		s.sqsClientMock.EXPECT().
 			ReceiveMessage(gomock.Any(), gomock.Any()).
-			Return(&sqs.ReceiveMessageOutput{Messages: expectedMessages}, nil)
+			DoAndReturn(func(ctx context.Context, input *sqs.ReceiveMessageInput, opts ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+				s.Contains(input.MessageSystemAttributeNames, types.MessageSystemAttributeNameApproximateReceiveCount)
+				s.Equal([]string{"All"}, input.MessageAttributeNames)
+				s.Equal(int32(2), input.MaxNumberOfMessages)
+				s.Equal(int32(20), input.WaitTimeSeconds)
+				s.Equal(int32(120), input.VisibilityTimeout)
+				return &sqs.ReceiveMessageOutput{Messages: expectedMessages}, nil
+			})
 
-		messages, err := s.sqsAdapter.Receive(s.ctx, 2, 1)
+		messages, err := s.sqsAdapter.Receive(s.ctx, 2, 20*time.Second)
 
 		s.NoError(err)
 		s.Len(messages, 2)
-		s.Equal(*expectedMessages[0].MessageId, *messages[0].MessageId)
-		s.Equal(*expectedMessages[1].MessageId, *messages[1].MessageId)
+		s.Equal("msg-1", messages[0].ID)
+		s.Equal("body-1", string(messages[0].Body))
+		s.Equal(1, messages[0].Attempt)
+		s.Equal("msg-2", messages[1].ID)
+		s.Equal(3, messages[1].Attempt)
+		s.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", messages[1].Attributes["traceparent"])
+	})
+
+	st.Run("should settle messages through their receipt handle", func(t *testing.T) {
+		s.sqsClientMock.EXPECT().
+			ReceiveMessage(gomock.Any(), gomock.Any()).
+			Return(&sqs.ReceiveMessageOutput{Messages: []types.Message{{
+				MessageId:     aws.String("msg-1"),
+				ReceiptHandle: aws.String("handle-1"),
+				Body:          aws.String("body-1"),
+			}}}, nil)
+		messages, err := s.sqsAdapter.Receive(s.ctx, 1, 20*time.Second)
+		s.Require().NoError(err)
+		s.Require().Len(messages, 1)
+
+		s.sqsClientMock.EXPECT().
+			ChangeMessageVisibility(s.ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *sqs.ChangeMessageVisibilityInput, opts ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+				s.Equal("handle-1", *input.ReceiptHandle)
+				s.Equal(int32(120), input.VisibilityTimeout)
+				return &sqs.ChangeMessageVisibilityOutput{}, nil
+			})
+		s.NoError(messages[0].Extend(s.ctx, 2*time.Minute))
+
+		s.sqsClientMock.EXPECT().
+			ChangeMessageVisibility(s.ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *sqs.ChangeMessageVisibilityInput, opts ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+				s.Equal(int32(30), input.VisibilityTimeout)
+				return &sqs.ChangeMessageVisibilityOutput{}, nil
+			})
+		s.NoError(messages[0].Nack(s.ctx, 30*time.Second))
+
+		s.sqsClientMock.EXPECT().
+			DeleteMessage(s.ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *sqs.DeleteMessageInput, opts ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+				s.Equal("handle-1", *input.ReceiptHandle)
+				return &sqs.DeleteMessageOutput{}, nil
+			})
+		s.NoError(messages[0].Ack(s.ctx))
 	})
 
 	st.Run("should return error when SQS returns error", func(t *testing.T) {
 		expectedErr := fmt.Errorf("sqs error")
 		s.sqsClientMock.EXPECT().ReceiveMessage(gomock.Any(), gomock.Any()).Return(nil, expectedErr)
 
-		messages, err := s.sqsAdapter.Receive(s.ctx, 5, 2)
+		messages, err := s.sqsAdapter.Receive(s.ctx, 5, 2*time.Second)
 
 		s.Error(err)
 		s.Contains(err.Error(), "failed to receive messages from SQS")
@@ -141,3 +224,62 @@ func (s *sqsHandleTest) Test_Delete() {
 		s.Contains(err.Error(), "failed to delete message from SQS")
 	})
 }
+
+func (s *sqsHandleTest) Test_ChangeVisibility() {
+	st := s.T()
+
+	st.Run("should change message visibility successfully", func(t *testing.T) {
+		receiptHandle := "test-receipt-handle"
+
+		s.sqsClientMock.EXPECT().
+			ChangeMessageVisibility(s.ctx, gomock.AssignableToTypeOf(&sqs.ChangeMessageVisibilityInput{})).
+			DoAndReturn(func(ctx context.Context, input *sqs.ChangeMessageVisibilityInput, opts ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+				s.Equal("workQueueURL", *input.QueueUrl)
+				s.Equal(receiptHandle, *input.ReceiptHandle)
+				s.Equal(int32(120), input.VisibilityTimeout)
+				return &sqs.ChangeMessageVisibilityOutput{}, nil
+			})
+
+		err := s.sqsAdapter.ChangeVisibility(s.ctx, receiptHandle, 120)
+		s.NoError(err)
+	})
+
+	st.Run("should return error if ChangeMessageVisibility fails", func(t *testing.T) {
+		s.sqsClientMock.EXPECT().
+			ChangeMessageVisibility(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("visibility error"))
+
+		err := s.sqsAdapter.ChangeVisibility(s.ctx, "fail-receipt-handle", 120)
+		s.Error(err)
+		s.Contains(err.Error(), "failed to change message visibility in SQS")
+	})
+}
+
+func (s *sqsHandleTest) Test_Ping() {
+	st := s.T()
+
+	st.Run("should check both queues", func(t *testing.T) {
+		var queues []string
+		s.sqsClientMock.EXPECT().
+			GetQueueAttributes(s.ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, input *sqs.GetQueueAttributesInput, opts ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+				queues = append(queues, *input.QueueUrl)
+				return &sqs.GetQueueAttributesOutput{}, nil
+			}).
+			Times(2)
+
+		err := s.sqsAdapter.Ping(s.ctx)
+
+		s.NoError(err)
+		s.ElementsMatch([]string{"workQueueURL", "errorQueueURL"}, queues)
+	})
+
+	st.Run("should return error when a queue can't be reached", func(t *testing.T) {
+		s.sqsClientMock.EXPECT().
+			GetQueueAttributes(gomock.Any(), gomock.Any()).
+			Return(nil, &types.QueueDoesNotExist{})
+
+		err := s.sqsAdapter.Ping(s.ctx)
+		s.ErrorContains(err, "failed to get attributes of queue")
+	})
+}
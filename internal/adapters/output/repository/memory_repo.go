@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// MemoryVideoJobRepository keeps jobs and their history in memory, for
+// running the worker and its tests without Postgres. It follows the same
+// lease and status rules as the Postgres repository, and writes the outbox
+// messages of a finished job to its MemoryOutboxRepository.
+type MemoryVideoJobRepository struct {
+	mu      sync.Mutex
+	jobs    map[string]*model.VideoJob
+	emails  map[string]string
+	history []model.JobStatusHistory
+	outbox  *MemoryOutboxRepository
+}
+
+func NewMemoryVideoJobRepository(outbox *MemoryOutboxRepository) *MemoryVideoJobRepository {
+	return &MemoryVideoJobRepository{
+		jobs:   map[string]*model.VideoJob{},
+		emails: map[string]string{},
+		outbox: outbox,
+	}
+}
+
+// AddJob stores a job owned by a user with the given email, like the API
+// does before enqueuing it. Empty IDs, statuses and creation times are
+// filled in; the stored job is returned.
+func (r *MemoryVideoJobRepository) AddJob(job model.VideoJob, email string) model.VideoJob {
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+	if job.Status == "" {
+		job.Status = model.VideoStatusQueued
+	}
+	if job.CreatedAt == "" {
+		job.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = &job
+	r.emails[job.UserID] = email
+	return job
+}
+
+// Job returns a copy of the stored job.
+func (r *MemoryVideoJobRepository) Job(jobID string) (model.VideoJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return model.VideoJob{}, false
+	}
+	return *job, true
+}
+
+// Ping always succeeds: the jobs live in the worker's own memory.
+func (r *MemoryVideoJobRepository) Ping(_ context.Context) error {
+	return nil
+}
+
+func (r *MemoryVideoJobRepository) GetJobByID(_ context.Context, jobID string) (*model.VideoJobDTO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("job with id '%s' not found: %w", jobID, model.ErrJobNotFound)
+	}
+	return &model.VideoJobDTO{
+		ID:         job.ID,
+		Status:     job.Status,
+		CreatedAt:  job.CreatedAt,
+		OutputPath: job.OutputPath,
+		UserID:     job.UserID,
+		Email:      r.emails[job.UserID],
+		VideoPath:  job.VideoPath,
+	}, nil
+}
+
+func (r *MemoryVideoJobRepository) SaveVideoMetadata(_ context.Context, jobID, workerID string, metadata model.VideoMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || job.LeaseOwner == nil || *job.LeaseOwner != workerID {
+		return fmt.Errorf("job '%s' is no longer leased by '%s': %w", jobID, workerID, model.ErrLeaseLost)
+	}
+	job.VideoMetadata = &metadata
+	return nil
+}
+
+func (r *MemoryVideoJobRepository) MarkCompleted(_ context.Context, jobID, workerID, outputPath string, stats model.JobStats, outbox []model.OutboxMessage) (int64, error) {
+	return r.finishJob(jobID, workerID, model.VideoStatusCompleted, nil, outbox, func(job *model.VideoJob) {
+		job.OutputPath = &outputPath
+		job.FrameCount = &stats.FrameCount
+		job.ArchiveSize = &stats.ArchiveSize
+		if stats.Download != nil {
+			job.DownloadURL = &stats.Download.URL
+			job.DownloadURLExpiresAt = &stats.Download.ExpiresAt
+		}
+	})
+}
+
+func (r *MemoryVideoJobRepository) MarkFailed(_ context.Context, jobID, workerID string, failure model.JobFailure, outbox []model.OutboxMessage) (int64, error) {
+	return r.finishJob(jobID, workerID, model.VideoStatusFailed, &failure.Message, outbox, func(job *model.VideoJob) {
+		job.FailureCode = &failure.Code
+		job.FailureStage = &failure.Stage
+		job.FailureMessage = &failure.Message
+	})
+}
+
+// finishJob moves a processing job leased by workerID to status, recording
+// its history entry and outbox messages at the same time. It returns zero
+// rows when the lease was lost.
+func (r *MemoryVideoJobRepository) finishJob(jobID, workerID string, status model.VideoStatus, reason *string, outbox []model.OutboxMessage, update func(*model.VideoJob)) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || job.Status != model.VideoStatusProcessing || job.LeaseOwner == nil || *job.LeaseOwner != workerID {
+		return 0, nil
+	}
+
+	update(job)
+	job.Status = status
+	job.LeaseOwner = nil
+	job.LeaseExpiresAt = nil
+	r.record(jobID, status, reason, workerID)
+	if r.outbox != nil {
+		r.outbox.add(outbox)
+	}
+	return 1, nil
+}
+
+func (r *MemoryVideoJobRepository) ListJobHistory(_ context.Context, jobID string) ([]model.JobStatusHistory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lo.Filter(r.history, func(entry model.JobStatusHistory, _ int) bool {
+		return entry.JobID == jobID
+	}), nil
+}
+
+func (r *MemoryVideoJobRepository) ClaimJob(_ context.Context, jobID, workerID string, leaseDuration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok {
+		// Like the Postgres repository, which can't tell a missing row from
+		// one locked by another worker.
+		return fmt.Errorf("job '%s' is locked: %w", jobID, model.ErrJobAlreadyClaimed)
+	}
+
+	now := time.Now()
+	if job.Status == model.VideoStatusProcessing &&
+		job.LeaseOwner != nil && *job.LeaseOwner != workerID &&
+		job.LeaseExpiresAt != nil && job.LeaseExpiresAt.After(now) {
+		return fmt.Errorf("job '%s' is leased by '%s' until %s: %w", jobID, *job.LeaseOwner, job.LeaseExpiresAt.Format(time.RFC3339), model.ErrJobAlreadyClaimed)
+	}
+	if !job.Status.CanTransitionTo(model.VideoStatusProcessing) {
+		return fmt.Errorf("job '%s' is '%s': %w", jobID, job.Status, model.ErrInvalidTransition)
+	}
+
+	job.Status = model.VideoStatusProcessing
+	job.LeaseOwner = &workerID
+	job.LeaseExpiresAt = lo.ToPtr(now.Add(leaseDuration))
+	r.record(jobID, model.VideoStatusProcessing, nil, workerID)
+	return nil
+}
+
+func (r *MemoryVideoJobRepository) ExtendLease(_ context.Context, jobID, workerID string, leaseDuration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || job.Status != model.VideoStatusProcessing || job.LeaseOwner == nil || *job.LeaseOwner != workerID {
+		return fmt.Errorf("job '%s' is no longer leased by '%s': %w", jobID, workerID, model.ErrLeaseLost)
+	}
+	job.LeaseExpiresAt = lo.ToPtr(time.Now().Add(leaseDuration))
+	return nil
+}
+
+func (r *MemoryVideoJobRepository) ReleaseJob(_ context.Context, jobID, workerID, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok || job.Status != model.VideoStatusProcessing || job.LeaseOwner == nil || *job.LeaseOwner != workerID {
+		return nil
+	}
+	job.Status = model.VideoStatusQueued
+	job.LeaseOwner = nil
+	job.LeaseExpiresAt = nil
+	r.record(jobID, model.VideoStatusQueued, &reason, workerID)
+	return nil
+}
+
+func (r *MemoryVideoJobRepository) ReleaseExpiredLeases(_ context.Context, workerID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var released int64
+	for _, job := range r.jobs {
+		if job.Status != model.VideoStatusProcessing || job.LeaseExpiresAt == nil || !job.LeaseExpiresAt.Before(now) {
+			continue
+		}
+		job.Status = model.VideoStatusQueued
+		job.LeaseOwner = nil
+		job.LeaseExpiresAt = nil
+		r.record(job.ID, model.VideoStatusQueued, lo.ToPtr("lease expired"), workerID)
+		released++
+	}
+	return released, nil
+}
+
+// record appends a history entry. The caller must hold the lock.
+func (r *MemoryVideoJobRepository) record(jobID string, status model.VideoStatus, reason *string, workerID string) {
+	r.history = append(r.history, model.JobStatusHistory{
+		ID:        uuid.NewString(),
+		JobID:     jobID,
+		Status:    status,
+		Reason:    reason,
+		WorkerID:  workerID,
+		CreatedAt: time.Now(),
+	})
+}
+
+// MemoryOutboxRepository keeps outbox messages in memory, alongside a
+// MemoryVideoJobRepository.
+type MemoryOutboxRepository struct {
+	mu       sync.Mutex
+	messages []*model.OutboxMessage
+}
+
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{}
+}
+
+// Messages returns a copy of every message written so far, sent or not.
+func (r *MemoryOutboxRepository) Messages() []model.OutboxMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lo.Map(r.messages, func(msg *model.OutboxMessage, _ int) model.OutboxMessage {
+		return *msg
+	})
+}
+
+func (r *MemoryOutboxRepository) add(messages []model.OutboxMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, msg := range messages {
+		msg.ID = uuid.NewString()
+		msg.CreatedAt = now
+		if msg.AvailableAt.IsZero() {
+			msg.AvailableAt = now
+		}
+		r.messages = append(r.messages, &msg)
+	}
+}
+
+// ClaimDue returns up to limit unsent messages whose AvailableAt has passed,
+// oldest first, and hides them from other claims for lease.
+func (r *MemoryOutboxRepository) ClaimDue(_ context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	due := lo.Filter(r.messages, func(msg *model.OutboxMessage, _ int) bool {
+		return msg.SentAt == nil && !msg.AvailableAt.After(now)
+	})
+	slices.SortStableFunc(due, func(a, b *model.OutboxMessage) int {
+		return cmp.Compare(a.AvailableAt.UnixNano(), b.AvailableAt.UnixNano())
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]model.OutboxMessage, 0, len(due))
+	for _, msg := range due {
+		claimed = append(claimed, *msg)
+		msg.AvailableAt = now.Add(lease)
+	}
+	return claimed, nil
+}
+
+func (r *MemoryOutboxRepository) MarkSent(_ context.Context, id string) error {
+	return r.update(id, func(msg *model.OutboxMessage) {
+		msg.SentAt = lo.ToPtr(time.Now())
+	})
+}
+
+func (r *MemoryOutboxRepository) MarkFailed(_ context.Context, id string, cause string, retryAt time.Time) error {
+	return r.update(id, func(msg *model.OutboxMessage) {
+		msg.Attempts++
+		msg.LastError = &cause
+		msg.AvailableAt = retryAt
+	})
+}
+
+func (r *MemoryOutboxRepository) update(id string, fn func(*model.OutboxMessage)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msg, ok := lo.Find(r.messages, func(msg *model.OutboxMessage) bool { return msg.ID == id })
+	if !ok {
+		return fmt.Errorf("outbox message '%s' not found", id)
+	}
+	fn(msg)
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/repository"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryVideoJobRepository_ClaimJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should lease a queued job", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(nil)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1", VideoPath: "uploads/video.mp4"}, "user@example.com")
+
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute))
+
+		stored, _ := repo.Job(job.ID)
+		assert.Equal(t, model.VideoStatusProcessing, stored.Status)
+		assert.Equal(t, "worker-1", *stored.LeaseOwner)
+		history, err := repo.ListJobHistory(ctx, job.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, model.VideoStatusProcessing, history[0].Status)
+	})
+
+	t.Run("should refuse a job leased by another worker", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(nil)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1"}, "user@example.com")
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute))
+
+		err := repo.ClaimJob(ctx, job.ID, "worker-2", time.Minute)
+		assert.ErrorIs(t, err, model.ErrJobAlreadyClaimed)
+	})
+
+	t.Run("should refuse a finished job", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(nil)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1", Status: model.VideoStatusCompleted}, "user@example.com")
+
+		err := repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute)
+		assert.ErrorIs(t, err, model.ErrInvalidTransition)
+	})
+
+	t.Run("should return the errors of the Postgres repository for an unknown job", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(nil)
+
+		assert.ErrorIs(t, repo.ClaimJob(ctx, "missing", "worker-1", time.Minute), model.ErrJobAlreadyClaimed)
+		_, err := repo.GetJobByID(ctx, "missing")
+		assert.ErrorIs(t, err, model.ErrJobNotFound)
+	})
+}
+
+func TestMemoryVideoJobRepository_Leases(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should only let the owner extend its lease", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(nil)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1"}, "user@example.com")
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute))
+
+		assert.NoError(t, repo.ExtendLease(ctx, job.ID, "worker-1", time.Hour))
+		assert.ErrorIs(t, repo.ExtendLease(ctx, job.ID, "worker-2", time.Hour), model.ErrLeaseLost)
+		assert.ErrorIs(t, repo.SaveVideoMetadata(ctx, job.ID, "worker-2", model.VideoMetadata{}), model.ErrLeaseLost)
+	})
+
+	t.Run("should requeue jobs whose lease expired", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(nil)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1"}, "user@example.com")
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", -time.Second))
+
+		released, err := repo.ReleaseExpiredLeases(ctx, "worker-2")
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 1, released)
+		stored, _ := repo.Job(job.ID)
+		assert.Equal(t, model.VideoStatusQueued, stored.Status)
+		assert.Nil(t, stored.LeaseOwner)
+	})
+}
+
+func TestMemoryVideoJobRepository_Finish(t *testing.T) {
+	ctx := context.Background()
+	messages := []model.OutboxMessage{{Destination: model.OutboxEvents, EventType: "job.completed", Payload: "{}"}}
+
+	t.Run("should complete a leased job and write its outbox messages", func(t *testing.T) {
+		outbox := repository.NewMemoryOutboxRepository()
+		repo := repository.NewMemoryVideoJobRepository(outbox)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1"}, "user@example.com")
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute))
+
+		rows, err := repo.MarkCompleted(ctx, job.ID, "worker-1", "output/job.zip", model.JobStats{FrameCount: 3, ArchiveSize: 10}, messages)
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 1, rows)
+		dto, err := repo.GetJobByID(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.VideoStatusCompleted, dto.Status)
+		assert.Equal(t, "user@example.com", dto.Email)
+		require.Len(t, outbox.Messages(), 1)
+		assert.NotEmpty(t, outbox.Messages()[0].ID)
+	})
+
+	t.Run("should not touch a job whose lease was lost", func(t *testing.T) {
+		outbox := repository.NewMemoryOutboxRepository()
+		repo := repository.NewMemoryVideoJobRepository(outbox)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1"}, "user@example.com")
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute))
+
+		rows, err := repo.MarkFailed(ctx, job.ID, "worker-2", model.JobFailure{Message: "boom"}, messages)
+		require.NoError(t, err)
+
+		assert.Zero(t, rows)
+		assert.Empty(t, outbox.Messages())
+	})
+}
+
+func TestMemoryOutboxRepository(t *testing.T) {
+	ctx := context.Background()
+	newOutbox := func(t *testing.T) *repository.MemoryOutboxRepository {
+		outbox := repository.NewMemoryOutboxRepository()
+		repo := repository.NewMemoryVideoJobRepository(outbox)
+		job := repo.AddJob(model.VideoJob{UserID: "user-1"}, "user@example.com")
+		require.NoError(t, repo.ClaimJob(ctx, job.ID, "worker-1", time.Minute))
+		_, err := repo.MarkCompleted(ctx, job.ID, "worker-1", "output/job.zip", model.JobStats{}, []model.OutboxMessage{
+			{Destination: model.OutboxEvents, EventType: "job.completed"},
+			{Destination: model.OutboxEvents, EventType: "job.completed"},
+		})
+		require.NoError(t, err)
+		return outbox
+	}
+
+	t.Run("should hide claimed messages for the lease", func(t *testing.T) {
+		outbox := newOutbox(t)
+
+		claimed, err := outbox.ClaimDue(ctx, 1, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+
+		rest, err := outbox.ClaimDue(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		assert.NotEqual(t, claimed[0].ID, rest[0].ID)
+	})
+
+	t.Run("should not claim sent messages", func(t *testing.T) {
+		outbox := newOutbox(t)
+		claimed, err := outbox.ClaimDue(ctx, 10, 0)
+		require.NoError(t, err)
+		for _, msg := range claimed {
+			require.NoError(t, outbox.MarkSent(ctx, msg.ID))
+		}
+
+		again, err := outbox.ClaimDue(ctx, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, again)
+	})
+
+	t.Run("should record failures and retry later", func(t *testing.T) {
+		outbox := newOutbox(t)
+		claimed, err := outbox.ClaimDue(ctx, 1, 0)
+		require.NoError(t, err)
+
+		require.NoError(t, outbox.MarkFailed(ctx, claimed[0].ID, "broker down", time.Now().Add(time.Hour)))
+
+		failed, _ := lo.Find(outbox.Messages(), func(msg model.OutboxMessage) bool { return msg.ID == claimed[0].ID })
+		assert.Equal(t, 1, failed.Attempts)
+		assert.Equal(t, "broker down", *failed.LastError)
+		assert.Error(t, outbox.MarkSent(ctx, "missing"))
+	})
+}
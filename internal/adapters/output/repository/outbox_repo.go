@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *outboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// ClaimDue returns up to limit unsent messages that are due, oldest first,
+// and hides them from other relays for lease. Messages whose relay crashed
+// before marking them become due again once the lease runs out.
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(skipLocked).
+			Where("sent_at IS NULL AND available_at <= ?", now).
+			Order("available_at ASC").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil {
+			return fmt.Errorf("error fetching due outbox messages: %w", err)
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		err = tx.Model(&model.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("available_at", now.Add(lease)).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim outbox messages: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkSent records that the message was published.
+func (r *outboxRepository) MarkSent(ctx context.Context, id string) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Update("sent_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message '%s' as sent: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish and makes the message due again at
+// retryAt.
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, cause string, retryAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   cause,
+			"available_at": retryAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record failed publish of outbox message '%s': %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/repository"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type outboxRepositoryTestSuite struct {
+	suite.Suite
+	mockDB  *gorm.DB
+	mockSQL sqlmock.Sqlmock
+	repo    ports.OutboxRepository
+	ctx     context.Context
+}
+
+func TestOutboxSuite(t *testing.T) {
+	suite.Run(t, new(outboxRepositoryTestSuite))
+}
+
+func (ots *outboxRepositoryTestSuite) SetupTest() {
+	ots.mockSQL, ots.mockDB = tests.BuildMockDB(ots.T())
+}
+
+func (ots *outboxRepositoryTestSuite) AfterTest(_, _ string) {
+	assert.NoError(ots.T(), ots.mockSQL.ExpectationsWereMet())
+}
+
+func (ots *outboxRepositoryTestSuite) BeforeTest(_, _ string) {
+	ots.ctx = context.Background()
+	ots.repo = repository.NewOutboxRepository(ots.mockDB)
+}
+
+func (ots *outboxRepositoryTestSuite) Test_ClaimDue() {
+	const selectRegexp = `(?i)SELECT .* FROM .*tb_job_outbox.*WHERE sent_at IS NULL AND available_at <= .*ORDER BY available_at ASC LIMIT .*FOR UPDATE SKIP LOCKED`
+	const updateRegexp = `(?i)UPDATE .*tb_job_outbox.*SET .*available_at.*WHERE id IN .*`
+
+	ots.T().Run("Should claim due messages for the lease", func(t *testing.T) {
+		ots.mockSQL.ExpectBegin()
+		ots.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(sqlmock.AnyArg(), 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "destination", "event_type", "payload", "headers", "attempts"}).
+				AddRow("0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f", "194f2506-3a19-42fb-91a0-50442a1bfcfd", "events", "job.completed", `{"type":"job.completed"}`, `{"traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}`, 2))
+		ots.mockSQL.ExpectExec(updateRegexp).
+			WithArgs(sqlmock.AnyArg(), "0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ots.mockSQL.ExpectCommit()
+
+		messages, err := ots.repo.ClaimDue(ots.ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, model.OutboxEvents, messages[0].Destination)
+		assert.Equal(t, 2, messages[0].Attempts)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", messages[0].Headers["traceparent"])
+	})
+
+	ots.T().Run("Should do nothing when no message is due", func(t *testing.T) {
+		ots.mockSQL.ExpectBegin()
+		ots.mockSQL.ExpectQuery(selectRegexp).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		ots.mockSQL.ExpectCommit()
+
+		messages, err := ots.repo.ClaimDue(ots.ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	ots.T().Run("Should return error when db returns error", func(t *testing.T) {
+		dbErr := fmt.Errorf("db select error")
+		ots.mockSQL.ExpectBegin()
+		ots.mockSQL.ExpectQuery(selectRegexp).
+			WillReturnError(dbErr)
+		ots.mockSQL.ExpectRollback()
+
+		messages, err := ots.repo.ClaimDue(ots.ctx, 10, time.Minute)
+		assert.Nil(t, messages)
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
+
+func (ots *outboxRepositoryTestSuite) Test_MarkSent() {
+	ots.T().Run("Should set sent_at", func(t *testing.T) {
+		ots.mockSQL.ExpectBegin()
+		ots.mockSQL.ExpectExec(`(?i)UPDATE .*tb_job_outbox.*SET .*sent_at.*WHERE id = .*`).
+			WithArgs(sqlmock.AnyArg(), "0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ots.mockSQL.ExpectCommit()
+
+		err := ots.repo.MarkSent(ots.ctx, "0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f")
+		assert.NoError(t, err)
+	})
+}
+
+func (ots *outboxRepositoryTestSuite) Test_MarkFailed() {
+	ots.T().Run("Should count the attempt and reschedule the message", func(t *testing.T) {
+		retryAt := time.Now().Add(30 * time.Second)
+		ots.mockSQL.ExpectBegin()
+		ots.mockSQL.ExpectExec(`(?i)UPDATE .*tb_job_outbox.*SET .*attempts.*=attempts \+ 1.*available_at.*last_error.*WHERE id = .*`).
+			WithArgs(retryAt, "queue unavailable", "0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ots.mockSQL.ExpectCommit()
+
+		err := ots.repo.MarkFailed(ots.ctx, "0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f", "queue unavailable", retryAt)
+		assert.NoError(t, err)
+	})
+
+	ots.T().Run("Should return error when db returns error", func(t *testing.T) {
+		dbErr := fmt.Errorf("db update error")
+		ots.mockSQL.ExpectBegin()
+		ots.mockSQL.ExpectExec(`(?i)UPDATE .*tb_job_outbox.*`).
+			WillReturnError(dbErr)
+		ots.mockSQL.ExpectRollback()
+
+		err := ots.repo.MarkFailed(ots.ctx, "0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f", "queue unavailable", time.Now())
+		assert.Contains(t, err.Error(), "failed to record failed publish")
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
@@ -2,12 +2,20 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/samber/lo"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// skipLocked makes a SELECT lock the returned rows and skip the ones already
+// locked by another transaction instead of waiting for them.
+var skipLocked = clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}
+
 type videoJobRepository struct {
 	db *gorm.DB
 }
@@ -15,6 +23,19 @@ type videoJobRepository struct {
 func NewVideoJobRepository(db *gorm.DB) *videoJobRepository {
 	return &videoJobRepository{db: db}
 }
+
+// Ping checks that the database accepts connections.
+func (r *videoJobRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
 func (r *videoJobRepository) GetJobByID(ctx context.Context, jobID string) (*model.VideoJobDTO, error) {
 	var job model.VideoJobDTO
 	err := r.db.WithContext(ctx).
@@ -24,17 +45,238 @@ func (r *videoJobRepository) GetJobByID(ctx context.Context, jobID string) (*mod
 		Where("tb_video_jobs.id = ?", jobID).
 		First(&job).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("job with id '%s' not found", jobID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("job with id '%s' not found: %w", jobID, model.ErrJobNotFound)
 		}
 		return nil, fmt.Errorf("error fetching job with id '%s': %w", jobID, err)
 	}
 	return &job, nil
 }
 
-func (r *videoJobRepository) UpdateJobStatus(ctx context.Context, videoJob *model.VideoJob) error {
-	if err := r.db.WithContext(ctx).Save(videoJob).Error; err != nil {
-		return fmt.Errorf("failed to update video job: %w", err)
+// SaveVideoMetadata stores what was probed from the job's input video while
+// workerID still owns the job's lease.
+func (r *videoJobRepository) SaveVideoMetadata(ctx context.Context, jobID, workerID string, metadata model.VideoMetadata) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.VideoJob{}).
+		Where("id = ? AND lease_owner = ?", jobID, workerID).
+		Update("video_metadata", metadata)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save video metadata of job '%s': %w", jobID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job '%s' is no longer leased by '%s': %w", jobID, workerID, model.ErrLeaseLost)
+	}
+	return nil
+}
+
+// MarkCompleted finishes a job leased by workerID, storing where its archive
+// was uploaded and, when there is one, the link users download it from. It
+// returns how many rows were updated: zero means the job is no longer
+// processing under this worker's lease and nothing was written, the outbox
+// messages included.
+func (r *videoJobRepository) MarkCompleted(ctx context.Context, jobID, workerID, outputPath string, stats model.JobStats, outbox []model.OutboxMessage) (int64, error) {
+	columns := map[string]any{
+		"output_path":        outputPath,
+		"frame_count":        stats.FrameCount,
+		"archive_size_bytes": stats.ArchiveSize,
+	}
+	if stats.Download != nil {
+		columns["download_url"] = stats.Download.URL
+		columns["download_url_expires_at"] = stats.Download.ExpiresAt
+	}
+	return r.finishJob(ctx, jobID, workerID, model.VideoStatusCompleted, columns, nil, outbox)
+}
+
+// MarkFailed fails a job leased by workerID, storing the failure's code, stage
+// and message on the job and the message in its history. Like MarkCompleted,
+// it returns zero rows when the lease was lost in the meantime.
+func (r *videoJobRepository) MarkFailed(ctx context.Context, jobID, workerID string, failure model.JobFailure, outbox []model.OutboxMessage) (int64, error) {
+	return r.finishJob(ctx, jobID, workerID, model.VideoStatusFailed, map[string]any{
+		"failure_code":    failure.Code,
+		"failure_stage":   failure.Stage,
+		"failure_message": failure.Message,
+	}, &failure.Message, outbox)
+}
+
+// finishJob moves a processing job to status and releases its lease, touching
+// only those columns plus the given ones, and appends its history entry in the
+// same transaction so the audit trail never diverges from tb_video_jobs. The
+// outbox messages are written in that transaction too, so the events are
+// relayed if and only if the status changed.
+func (r *videoJobRepository) finishJob(ctx context.Context, jobID, workerID string, status model.VideoStatus, columns map[string]any, reason *string, outbox []model.OutboxMessage) (int64, error) {
+	columns["status"] = status
+	columns["lease_owner"] = nil
+	columns["lease_expires_at"] = nil
+
+	var updated int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.VideoJob{}).
+			Where("id = ? AND status = ? AND lease_owner = ?", jobID, model.VideoStatusProcessing, workerID).
+			Updates(columns)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update video job '%s': %w", jobID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		history := &model.JobStatusHistory{JobID: jobID, Status: status, Reason: reason, WorkerID: workerID}
+		if err := tx.Create(history).Error; err != nil {
+			return fmt.Errorf("failed to record status history for job '%s': %w", jobID, err)
+		}
+		if len(outbox) > 0 {
+			if err := tx.Create(&outbox).Error; err != nil {
+				return fmt.Errorf("failed to write outbox messages for job '%s': %w", jobID, err)
+			}
+		}
+
+		updated = result.RowsAffected
+		return nil
+	})
+	return updated, err
+}
+
+func (r *videoJobRepository) ListJobHistory(ctx context.Context, jobID string) ([]model.JobStatusHistory, error) {
+	var history []model.JobStatusHistory
+	err := r.db.WithContext(ctx).
+		Where("job_id = ?", jobID).
+		Order("created_at ASC").
+		Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching status history for job '%s': %w", jobID, err)
+	}
+	return history, nil
+}
+
+// ClaimJob atomically moves the job to processing under a lease owned by
+// workerID. It returns model.ErrJobAlreadyClaimed when another worker holds
+// the row lock or a lease that has not expired yet.
+func (r *videoJobRepository) ClaimJob(ctx context.Context, jobID, workerID string, leaseDuration time.Duration) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var job model.VideoJob
+		err := tx.Clauses(skipLocked).Where("id = ?", jobID).First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The job was found before claiming, so an empty result means
+			// another worker is claiming it right now.
+			return fmt.Errorf("job '%s' is locked: %w", jobID, model.ErrJobAlreadyClaimed)
+		}
+		if err != nil {
+			return fmt.Errorf("error locking job with id '%s': %w", jobID, err)
+		}
+
+		now := time.Now()
+		if job.Status == model.VideoStatusProcessing &&
+			job.LeaseOwner != nil && *job.LeaseOwner != workerID &&
+			job.LeaseExpiresAt != nil && job.LeaseExpiresAt.After(now) {
+			return fmt.Errorf("job '%s' is leased by '%s' until %s: %w", jobID, *job.LeaseOwner, job.LeaseExpiresAt.Format(time.RFC3339), model.ErrJobAlreadyClaimed)
+		}
+		if !job.Status.CanTransitionTo(model.VideoStatusProcessing) {
+			return fmt.Errorf("job '%s' is '%s': %w", jobID, job.Status, model.ErrInvalidTransition)
+		}
+
+		err = tx.Model(&job).Updates(map[string]any{
+			"status":           model.VideoStatusProcessing,
+			"lease_owner":      workerID,
+			"lease_expires_at": now.Add(leaseDuration),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim job '%s': %w", jobID, err)
+		}
+
+		history := &model.JobStatusHistory{JobID: jobID, Status: model.VideoStatusProcessing, WorkerID: workerID}
+		if err := tx.Create(history).Error; err != nil {
+			return fmt.Errorf("failed to record status history for job '%s': %w", jobID, err)
+		}
+		return nil
+	})
+}
+
+// ExtendLease pushes the lease expiry forward while workerID still owns it.
+func (r *videoJobRepository) ExtendLease(ctx context.Context, jobID, workerID string, leaseDuration time.Duration) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.VideoJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", jobID, model.VideoStatusProcessing, workerID).
+		Update("lease_expires_at", time.Now().Add(leaseDuration))
+	if result.Error != nil {
+		return fmt.Errorf("failed to extend lease of job '%s': %w", jobID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job '%s' is no longer leased by '%s': %w", jobID, workerID, model.ErrLeaseLost)
 	}
 	return nil
 }
+
+// ReleaseJob puts a job leased by workerID back to queued and gives up the
+// lease, so the redelivered message can claim it again right away instead of
+// waiting for the lease to expire. It does nothing when workerID no longer
+// holds the lease.
+func (r *videoJobRepository) ReleaseJob(ctx context.Context, jobID, workerID, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.VideoJob{}).
+			Where("id = ? AND status = ? AND lease_owner = ?", jobID, model.VideoStatusProcessing, workerID).
+			Updates(map[string]any{
+				"status":           model.VideoStatusQueued,
+				"lease_owner":      nil,
+				"lease_expires_at": nil,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to release job '%s': %w", jobID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		history := &model.JobStatusHistory{JobID: jobID, Status: model.VideoStatusQueued, Reason: &reason, WorkerID: workerID}
+		if err := tx.Create(history).Error; err != nil {
+			return fmt.Errorf("failed to record status history for job '%s': %w", jobID, err)
+		}
+		return nil
+	})
+}
+
+// ReleaseExpiredLeases puts processing jobs whose lease expired back to
+// queued, so a redelivered message can claim them again. It returns how many
+// jobs were released.
+func (r *videoJobRepository) ReleaseExpiredLeases(ctx context.Context, workerID string) (int64, error) {
+	var released int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var jobIDs []string
+		err := tx.Model(&model.VideoJob{}).
+			Clauses(skipLocked).
+			Where("status = ? AND lease_expires_at < ?", model.VideoStatusProcessing, time.Now()).
+			Pluck("id", &jobIDs).Error
+		if err != nil {
+			return fmt.Errorf("error fetching jobs with expired leases: %w", err)
+		}
+		if len(jobIDs) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&model.VideoJob{}).
+			Where("id IN ?", jobIDs).
+			Updates(map[string]any{
+				"status":           model.VideoStatusQueued,
+				"lease_owner":      nil,
+				"lease_expires_at": nil,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to release expired leases: %w", result.Error)
+		}
+
+		history := lo.Map(jobIDs, func(jobID string, _ int) model.JobStatusHistory {
+			return model.JobStatusHistory{
+				JobID:    jobID,
+				Status:   model.VideoStatusQueued,
+				Reason:   lo.ToPtr("lease expired"),
+				WorkerID: workerID,
+			}
+		})
+		if err := tx.Create(&history).Error; err != nil {
+			return fmt.Errorf("failed to record status history for released jobs: %w", err)
+		}
+
+		released = result.RowsAffected
+		return nil
+	})
+	return released, err
+}
@@ -88,7 +88,7 @@ func (rts *repositoryTestSuite) Test_GetJobByID() {
 
 		job, err := rts.repo.GetJobByID(rts.ctx, rts.videoDTO.ID)
 		assert.Nil(t, job)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, model.ErrJobNotFound)
 		assert.Contains(t, err.Error(), "not found")
 	})
 
@@ -107,61 +107,446 @@ func (rts *repositoryTestSuite) Test_GetJobByID() {
 	})
 }
 
-func (rts *repositoryTestSuite) Test_UpdateJobStatus() {
-	rts.T().Run("Should update job status successfully", func(t *testing.T) {
-		videoJob := &model.VideoJob{
-			ID:        rts.videoDTO.ID,
-			Status:    "completed",
-			CreatedAt: time.Now().String(),
-			UserID:    rts.videoDTO.UserID,
-			VideoPath: rts.videoDTO.VideoPath,
-		}
+func (rts *repositoryTestSuite) Test_SaveVideoMetadata() {
+	const sqlRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*video_metadata.*WHERE id = .* AND lease_owner = .*`
+	metadata := model.VideoMetadata{FormatName: "mov,mp4,m4a,3gp,3g2,mj2", DurationSeconds: 12.5, SizeBytes: 2048, VideoCodec: "h264", Width: 640, Height: 360, FrameRate: 25}
+
+	rts.T().Run("Should store the metadata as json", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(sqlRegexp).
+			WithArgs(`{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration_seconds":12.5,"size_bytes":2048,"video_codec":"h264","width":640,"height":360,"frame_rate":25}`, rts.videoDTO.ID, "worker-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.SaveVideoMetadata(rts.ctx, rts.videoDTO.ID, "worker-1", metadata)
+		assert.NoError(t, err)
+	})
 
-		const sqlRegexp = `(?i)UPDATE .*tb_video_jobs.*SET.*status.*WHERE.*id.*`
+	rts.T().Run("Should return lease lost when the worker no longer owns the job", func(t *testing.T) {
 		rts.mockSQL.ExpectBegin()
 		rts.mockSQL.ExpectExec(sqlRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.SaveVideoMetadata(rts.ctx, rts.videoDTO.ID, "worker-1", metadata)
+		assert.ErrorIs(t, err, model.ErrLeaseLost)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_MarkCompleted() {
+	const updateRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*archive_size_bytes.*frame_count.*lease_expires_at.*lease_owner.*output_path.*status.*WHERE id = .* AND status = .* AND lease_owner = .*`
+	const historyRegexp = `(?i)INSERT INTO .*tb_video_job_status_history.*job_id.*status.*reason.*worker_id.*`
+	stats := model.JobStats{FrameCount: 42, ArchiveSize: 1024}
+
+	rts.T().Run("Should only update completion columns and record history in one transaction", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
 			WithArgs(
-				videoJob.Status,
-				videoJob.CreatedAt,
-				videoJob.OutputPath,
-				videoJob.UserID,
-				videoJob.VideoPath,
-				videoJob.ID,
+				stats.ArchiveSize,
+				stats.FrameCount,
+				nil,
+				nil,
+				"output/video.zip",
+				model.VideoStatusCompleted,
+				rts.videoDTO.ID,
+				model.VideoStatusProcessing,
+				"worker-1",
 			).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WithArgs(rts.videoDTO.ID, model.VideoStatusCompleted, nil, "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
 		rts.mockSQL.ExpectCommit()
 
-		err := rts.repo.UpdateJobStatus(rts.ctx, videoJob)
+		updated, err := rts.repo.MarkCompleted(rts.ctx, rts.videoDTO.ID, "worker-1", "output/video.zip", stats, nil)
 		assert.NoError(t, err)
+		assert.Equal(t, int64(1), updated)
+	})
+
+	rts.T().Run("Should store the download link when there is one", func(t *testing.T) {
+		expiresAt := time.Now().Add(24 * time.Hour)
+		withLink := stats
+		withLink.Download = &model.DownloadLink{URL: "https://bucket.s3.amazonaws.com/output/video.zip?X-Amz-Signature=abc", ExpiresAt: expiresAt}
+
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(`(?i)UPDATE .*tb_video_jobs.*SET .*archive_size_bytes.*download_url.*download_url_expires_at.*frame_count.*output_path.*status.*`).
+			WithArgs(
+				stats.ArchiveSize,
+				withLink.Download.URL,
+				expiresAt,
+				stats.FrameCount,
+				nil,
+				nil,
+				"output/video.zip",
+				model.VideoStatusCompleted,
+				rts.videoDTO.ID,
+				model.VideoStatusProcessing,
+				"worker-1",
+			).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectCommit()
+
+		updated, err := rts.repo.MarkCompleted(rts.ctx, rts.videoDTO.ID, "worker-1", "output/video.zip", withLink, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), updated)
 	})
 
 	rts.T().Run("Should return error when db returns error", func(t *testing.T) {
-		videoJob := &model.VideoJob{
-			ID:        rts.videoDTO.ID,
-			Status:    "failed",
-			CreatedAt: time.Now().String(),
-			UserID:    rts.videoDTO.UserID,
-			VideoPath: rts.videoDTO.VideoPath,
-		}
-
-		const sqlRegexp = `(?i)UPDATE .*tb_video_jobs.*SET.*status.*WHERE.*id.*`
 		dbErr := fmt.Errorf("db update error")
 		rts.mockSQL.ExpectBegin()
-		rts.mockSQL.ExpectExec(sqlRegexp).
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnError(dbErr)
+		rts.mockSQL.ExpectRollback()
+
+		updated, err := rts.repo.MarkCompleted(rts.ctx, rts.videoDTO.ID, "worker-1", "output/video.zip", stats, nil)
+		assert.Zero(t, updated)
+		assert.Contains(t, err.Error(), "failed to update video job")
+		assert.ErrorIs(t, err, dbErr)
+	})
+
+	rts.T().Run("Should report no rows and skip history when the lease was lost", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		rts.mockSQL.ExpectCommit()
+
+		updated, err := rts.repo.MarkCompleted(rts.ctx, rts.videoDTO.ID, "worker-1", "output/video.zip", stats, nil)
+		assert.NoError(t, err)
+		assert.Zero(t, updated)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_MarkFailed() {
+	const updateRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*failure_code.*failure_message.*failure_stage.*lease_expires_at.*lease_owner.*status.*WHERE id = .* AND status = .* AND lease_owner = .*`
+	const historyRegexp = `(?i)INSERT INTO .*tb_video_job_status_history.*job_id.*status.*reason.*worker_id.*`
+	failure := model.JobFailure{
+		Code:    model.FailureProcessing,
+		Stage:   model.StageExtract,
+		Message: "ffmpeg execution error",
+		Stderr:  "Invalid data found when processing input",
+	}
+
+	rts.T().Run("Should fail the job and record the reason on it and in its history", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
 			WithArgs(
-				videoJob.Status,
-				videoJob.CreatedAt,
-				videoJob.OutputPath,
-				videoJob.UserID,
-				videoJob.VideoPath,
-				videoJob.ID,
+				model.FailureProcessing,
+				"ffmpeg execution error",
+				model.StageExtract,
+				nil,
+				nil,
+				model.VideoStatusFailed,
+				rts.videoDTO.ID,
+				model.VideoStatusProcessing,
+				"worker-1",
 			).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WithArgs(rts.videoDTO.ID, model.VideoStatusFailed, "ffmpeg execution error", "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectCommit()
+
+		updated, err := rts.repo.MarkFailed(rts.ctx, rts.videoDTO.ID, "worker-1", failure, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), updated)
+	})
+
+	rts.T().Run("Should write the outbox messages in the same transaction", func(t *testing.T) {
+		outbox := []model.OutboxMessage{{
+			JobID:       rts.videoDTO.ID,
+			Destination: model.OutboxErrorQueue,
+			EventType:   "job.error",
+			Payload:     `{"job_id":"194f2506-3a19-42fb-91a0-50442a1bfcfd"}`,
+			AvailableAt: time.Now(),
+		}}
+
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectQuery(`(?i)INSERT INTO .*tb_job_outbox.*job_id.*destination.*event_type.*payload.*`).
+			WithArgs(rts.videoDTO.ID, model.OutboxErrorQueue, "job.error", outbox[0].Payload, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "attempts"}).AddRow("0b7a4f7e-54a1-4f39-9a3b-2a1f4c6d7e8f", 0))
+		rts.mockSQL.ExpectCommit()
+
+		updated, err := rts.repo.MarkFailed(rts.ctx, rts.videoDTO.ID, "worker-1", failure, outbox)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), updated)
+	})
+
+	rts.T().Run("Should roll back the status update when the outbox insert fails", func(t *testing.T) {
+		dbErr := fmt.Errorf("db insert error")
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectQuery(`(?i)INSERT INTO .*tb_job_outbox.*`).
 			WillReturnError(dbErr)
 		rts.mockSQL.ExpectRollback()
 
-		err := rts.repo.UpdateJobStatus(rts.ctx, videoJob)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to update video job")
+		updated, err := rts.repo.MarkFailed(rts.ctx, rts.videoDTO.ID, "worker-1", failure, []model.OutboxMessage{{JobID: rts.videoDTO.ID}})
+		assert.Zero(t, updated)
+		assert.Contains(t, err.Error(), "failed to write outbox messages")
 		assert.ErrorIs(t, err, dbErr)
 	})
+
+	rts.T().Run("Should roll back the status update when history insert fails", func(t *testing.T) {
+		dbErr := fmt.Errorf("db insert error")
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WillReturnError(dbErr)
+		rts.mockSQL.ExpectRollback()
+
+		updated, err := rts.repo.MarkFailed(rts.ctx, rts.videoDTO.ID, "worker-1", failure, nil)
+		assert.Zero(t, updated)
+		assert.Contains(t, err.Error(), "failed to record status history")
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_ListJobHistory() {
+	const sqlRegexp = `(?i)SELECT .*FROM .*tb_video_job_status_history.*WHERE.*job_id.*ORDER BY created_at ASC`
+
+	rts.T().Run("Should list a job's history in order", func(t *testing.T) {
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "job_id", "status", "reason", "worker_id", "created_at"}).
+			AddRow("8a1f6b0e-7c57-4e5c-9a59-9c3c1b9a7c01", rts.videoDTO.ID, "processing", nil, "worker-1", now).
+			AddRow("8a1f6b0e-7c57-4e5c-9a59-9c3c1b9a7c02", rts.videoDTO.ID, "failed", "ffmpeg execution error", "worker-1", now.Add(time.Minute))
+		rts.mockSQL.ExpectQuery(sqlRegexp).
+			WithArgs(rts.videoDTO.ID).
+			WillReturnRows(rows)
+
+		history, err := rts.repo.ListJobHistory(rts.ctx, rts.videoDTO.ID)
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, model.VideoStatusProcessing, history[0].Status)
+		assert.Equal(t, model.VideoStatusFailed, history[1].Status)
+		assert.Equal(t, "ffmpeg execution error", *history[1].Reason)
+	})
+
+	rts.T().Run("Should return error when db returns error", func(t *testing.T) {
+		dbErr := fmt.Errorf("db connection error")
+		rts.mockSQL.ExpectQuery(sqlRegexp).
+			WithArgs(rts.videoDTO.ID).
+			WillReturnError(dbErr)
+
+		history, err := rts.repo.ListJobHistory(rts.ctx, rts.videoDTO.ID)
+		assert.Nil(t, history)
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_ClaimJob() {
+	const selectRegexp = `(?i)SELECT \* FROM .*tb_video_jobs.*WHERE id = .*FOR UPDATE SKIP LOCKED`
+	const updateRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*lease_expires_at.*lease_owner.*status.*WHERE .*id.*`
+	const historyRegexp = `(?i)INSERT INTO .*tb_video_job_status_history.*`
+	columns := []string{"id", "status", "created_at", "output_path", "user_id", "video_path", "lease_owner", "lease_expires_at"}
+
+	rts.T().Run("Should claim a queued job", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(rts.videoDTO.ID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				rts.videoDTO.ID, "queued", rts.videoDTO.CreatedAt, nil, rts.videoDTO.UserID, rts.videoDTO.VideoPath, nil, nil,
+			))
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WithArgs(sqlmock.AnyArg(), "worker-1", model.VideoStatusProcessing, rts.videoDTO.ID).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WithArgs(rts.videoDTO.ID, model.VideoStatusProcessing, nil, "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.ClaimJob(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.NoError(t, err)
+	})
+
+	rts.T().Run("Should reclaim a processing job whose lease expired", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(rts.videoDTO.ID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				rts.videoDTO.ID, "processing", rts.videoDTO.CreatedAt, nil, rts.videoDTO.UserID, rts.videoDTO.VideoPath, "worker-2", time.Now().Add(-time.Minute),
+			))
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.ClaimJob(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.NoError(t, err)
+	})
+
+	rts.T().Run("Should return already claimed when another worker holds a valid lease", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(rts.videoDTO.ID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				rts.videoDTO.ID, "processing", rts.videoDTO.CreatedAt, nil, rts.videoDTO.UserID, rts.videoDTO.VideoPath, "worker-2", time.Now().Add(time.Minute),
+			))
+		rts.mockSQL.ExpectRollback()
+
+		err := rts.repo.ClaimJob(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.ErrorIs(t, err, model.ErrJobAlreadyClaimed)
+	})
+
+	rts.T().Run("Should return already claimed when the row is locked", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(rts.videoDTO.ID, 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+		rts.mockSQL.ExpectRollback()
+
+		err := rts.repo.ClaimJob(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.ErrorIs(t, err, model.ErrJobAlreadyClaimed)
+	})
+
+	rts.T().Run("Should refuse to claim a completed job", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(rts.videoDTO.ID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				rts.videoDTO.ID, "completed", rts.videoDTO.CreatedAt, "output/video.zip", rts.videoDTO.UserID, rts.videoDTO.VideoPath, nil, nil,
+			))
+		rts.mockSQL.ExpectRollback()
+
+		err := rts.repo.ClaimJob(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.ErrorIs(t, err, model.ErrInvalidTransition)
+	})
+
+	rts.T().Run("Should refuse to claim a failed job that was not re-queued", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(rts.videoDTO.ID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				rts.videoDTO.ID, "failed", rts.videoDTO.CreatedAt, nil, rts.videoDTO.UserID, rts.videoDTO.VideoPath, nil, nil,
+			))
+		rts.mockSQL.ExpectRollback()
+
+		err := rts.repo.ClaimJob(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.ErrorIs(t, err, model.ErrInvalidTransition)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_ExtendLease() {
+	const sqlRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*lease_expires_at.*WHERE .*id = .* AND status = .* AND lease_owner = .*`
+
+	rts.T().Run("Should extend a lease owned by the worker", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(sqlRegexp).
+			WithArgs(sqlmock.AnyArg(), rts.videoDTO.ID, model.VideoStatusProcessing, "worker-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.ExtendLease(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.NoError(t, err)
+	})
+
+	rts.T().Run("Should return lease lost when the worker no longer owns the lease", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(sqlRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.ExtendLease(rts.ctx, rts.videoDTO.ID, "worker-1", time.Minute)
+		assert.ErrorIs(t, err, model.ErrLeaseLost)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_ReleaseJob() {
+	const updateRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*lease_expires_at.*lease_owner.*status.*WHERE .*id = .* AND status = .* AND lease_owner = .*`
+	const historyRegexp = `(?i)INSERT INTO .*tb_video_job_status_history.*`
+
+	rts.T().Run("Should put a job leased by the worker back to queued", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WithArgs(nil, nil, model.VideoStatusQueued, rts.videoDTO.ID, model.VideoStatusProcessing, "worker-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WithArgs(rts.videoDTO.ID, model.VideoStatusQueued, "attempt 1 failed: download", "worker-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.ReleaseJob(rts.ctx, rts.videoDTO.ID, "worker-1", "attempt 1 failed: download")
+		assert.NoError(t, err)
+	})
+
+	rts.T().Run("Should do nothing when the worker no longer owns the lease", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		rts.mockSQL.ExpectCommit()
+
+		err := rts.repo.ReleaseJob(rts.ctx, rts.videoDTO.ID, "worker-1", "processing interrupted")
+		assert.NoError(t, err)
+	})
+
+	rts.T().Run("Should return error when the update fails", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WillReturnError(fmt.Errorf("connection reset"))
+		rts.mockSQL.ExpectRollback()
+
+		err := rts.repo.ReleaseJob(rts.ctx, rts.videoDTO.ID, "worker-1", "processing interrupted")
+		assert.ErrorContains(t, err, "failed to release job")
+	})
+}
+
+func (rts *repositoryTestSuite) Test_ReleaseExpiredLeases() {
+	const selectRegexp = `(?i)SELECT .*id.* FROM .*tb_video_jobs.*WHERE status = .* AND lease_expires_at < .*FOR UPDATE SKIP LOCKED`
+	const updateRegexp = `(?i)UPDATE .*tb_video_jobs.*SET .*lease_expires_at.*lease_owner.*status.*WHERE id IN .*`
+	const historyRegexp = `(?i)INSERT INTO .*tb_video_job_status_history.*`
+
+	rts.T().Run("Should put jobs with expired leases back to queued", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WithArgs(model.VideoStatusProcessing, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(rts.videoDTO.ID))
+		rts.mockSQL.ExpectExec(updateRegexp).
+			WithArgs(nil, nil, model.VideoStatusQueued, rts.videoDTO.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		rts.mockSQL.ExpectQuery(historyRegexp).
+			WithArgs(rts.videoDTO.ID, model.VideoStatusQueued, "lease expired", "reaper-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("a6c5a1c4-9e3e-4bd4-9fd2-6b8f0f0b5d11", time.Now()))
+		rts.mockSQL.ExpectCommit()
+
+		released, err := rts.repo.ReleaseExpiredLeases(rts.ctx, "reaper-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), released)
+	})
+
+	rts.T().Run("Should do nothing when no lease expired", func(t *testing.T) {
+		rts.mockSQL.ExpectBegin()
+		rts.mockSQL.ExpectQuery(selectRegexp).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		rts.mockSQL.ExpectCommit()
+
+		released, err := rts.repo.ReleaseExpiredLeases(rts.ctx, "reaper-1")
+		assert.NoError(t, err)
+		assert.Zero(t, released)
+	})
+}
+
+func (rts *repositoryTestSuite) Test_Ping() {
+	rts.T().Run("Should ping the database", func(t *testing.T) {
+		err := repository.NewVideoJobRepository(rts.mockDB).Ping(rts.ctx)
+		assert.NoError(t, err)
+	})
+
+	rts.T().Run("Should return error when the database is closed", func(t *testing.T) {
+		sqlDB, err := rts.mockDB.DB()
+		assert.NoError(t, err)
+		rts.mockSQL.ExpectClose()
+		assert.NoError(t, sqlDB.Close())
+
+		err = repository.NewVideoJobRepository(rts.mockDB).Ping(rts.ctx)
+		assert.ErrorContains(t, err, "failed to ping database")
+	})
 }
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+)
+
+// maxResumeAttempts is how many times in a row a range is resumed without
+// receiving a single byte before the download gives up.
+const maxResumeAttempts = 3
+
+// downloadRanges writes the object into file, splitting it into PartSize
+// ranges fetched at most Concurrency at a time.
+func (a *S3Client) downloadRanges(ctx context.Context, objectKey string, head *s3.HeadObjectOutput, file *os.File) error {
+	size := aws.ToInt64(head.ContentLength)
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to allocate local file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	slots := make(chan struct{}, a.opts.Concurrency)
+	for start := int64(0); start < size; start += a.opts.PartSize {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			setErr(err)
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := a.downloadRange(ctx, objectKey, head.ETag, file, start, end); err != nil {
+				setErr(err)
+			}
+		}(start, min(start+a.opts.PartSize, size)-1)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// downloadRange writes bytes start to end (inclusive) of the object at the
+// same offsets in file. When the connection drops mid-stream the request is
+// resumed from the last byte written instead of starting over.
+func (a *S3Client) downloadRange(ctx context.Context, objectKey string, etag *string, file *os.File, start, end int64) error {
+	failures := 0
+	for {
+		written, err := a.copyRange(ctx, objectKey, etag, file, start, end)
+		start += written
+		if err == nil {
+			return nil
+		}
+
+		var interrupted *interruptedError
+		if !errors.As(err, &interrupted) || ctx.Err() != nil {
+			return err
+		}
+		if written > 0 {
+			failures = 0
+		}
+		failures++
+		if failures > maxResumeAttempts {
+			return fmt.Errorf("failed to download '%s' after %d attempts: %w", objectKey, maxResumeAttempts, interrupted.err)
+		}
+		logger.FromContext(ctx).Warn("Download interrupted, resuming.", "key", objectKey, "offset", start, "error", interrupted.err)
+	}
+}
+
+// copyRange makes a single ranged request and returns how many bytes it
+// wrote. Errors reading the body are returned as *interruptedError, since
+// the request can be resumed after them. IfMatch makes the request fail if
+// the object was replaced since the download started.
+func (a *S3Client) copyRange(ctx context.Context, objectKey string, etag *string, file *os.File, start, end int64) (int64, error) {
+	result, err := a.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(a.bucketName),
+		Key:     aws.String(objectKey),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		IfMatch: etag,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get object '%s' from S3: %w", objectKey, err)
+	}
+	defer result.Body.Close()
+
+	body := &trackingReader{r: result.Body}
+	written, err := io.Copy(io.NewOffsetWriter(file, start), io.LimitReader(body, end-start+1))
+	switch {
+	case body.err != nil:
+		return written, &interruptedError{err: body.err}
+	case err != nil:
+		return written, fmt.Errorf("failed to write local file: %w", err)
+	case written < end-start+1:
+		return written, &interruptedError{err: io.ErrUnexpectedEOF}
+	}
+	return written, nil
+}
+
+// interruptedError is a failure reading a response body.
+type interruptedError struct {
+	err error
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("failed to copy S3 content: %v", e.err)
+}
+
+func (e *interruptedError) Unwrap() error { return e.err }
+
+// trackingReader remembers the error of the reader it wraps, telling read
+// failures apart from write failures after an io.Copy.
+type trackingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (t *trackingReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF {
+		t.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// LocalStorage keeps objects as files under a root directory, the object key
+// being the file's path relative to it. It stands in for S3 when running the
+// worker without AWS.
+type LocalStorage struct {
+	root       string
+	linkExpiry time.Duration
+}
+
+// NewLocalStorage stores objects under root. Download links are file URLs
+// reported as valid for linkExpiry, like a presigned S3 URL.
+func NewLocalStorage(root string, linkExpiry time.Duration) *LocalStorage {
+	return &LocalStorage{root: root, linkExpiry: linkExpiry}
+}
+
+// Ping checks that the root directory exists.
+func (l *LocalStorage) Ping(_ context.Context) error {
+	info, err := os.Stat(l.root)
+	if err != nil {
+		return fmt.Errorf("failed to reach storage directory '%s': %w", l.root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("storage path '%s' is not a directory", l.root)
+	}
+	return nil
+}
+
+// path maps an object key to its file, refusing keys that would escape the
+// root directory.
+func (l *LocalStorage) path(objectKey string) (string, error) {
+	key := filepath.FromSlash(objectKey)
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid object key '%s'", objectKey)
+	}
+	return filepath.Join(l.root, key), nil
+}
+
+// DownloadFile copies the object's file into the job's workspace.
+func (l *LocalStorage) DownloadFile(_ context.Context, objectKey string, workspace *model.Workspace) (*model.DownloadedFile, error) {
+	path, err := l.path(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("object '%s' does not exist in '%s': %w", objectKey, l.root, model.ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to open object '%s': %w", objectKey, err)
+	}
+	defer file.Close()
+
+	return copyToWorkspace(objectKey, file, workspace)
+}
+
+// UploadFile copies a local file to the object's path.
+func (l *LocalStorage) UploadFile(ctx context.Context, localFilePath, objectKey string) error {
+	file, err := os.Open(localFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file '%s' for upload: %w", localFilePath, err)
+	}
+	defer file.Close()
+
+	return l.UploadStream(ctx, objectKey, file)
+}
+
+// UploadStream writes body to a temporary file next to the object's path and
+// renames it into place, so a partial upload is never seen as the object.
+func (l *LocalStorage) UploadStream(_ context.Context, objectKey string, body io.Reader) error {
+	path, err := l.path(objectKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", objectKey, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file for '%s': %w", objectKey, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		return fmt.Errorf("failed to write object '%s': %w", objectKey, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write object '%s': %w", objectKey, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store object '%s': %w", objectKey, err)
+	}
+	return nil
+}
+
+// PresignDownload returns the file URL of the object.
+func (l *LocalStorage) PresignDownload(_ context.Context, objectKey string) (*model.DownloadLink, error) {
+	path, err := l.path(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path of '%s': %w", objectKey, err)
+	}
+
+	link := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return &model.DownloadLink{URL: link.String(), ExpiresAt: time.Now().Add(l.linkExpiry)}, nil
+}
+
+// copyToWorkspace writes an object's content to the job's input file.
+func copyToWorkspace(objectKey string, content io.Reader, workspace *model.Workspace) (*model.DownloadedFile, error) {
+	localPath := workspace.Path("input" + filepath.Ext(objectKey))
+	localFile, err := os.Create(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer localFile.Close()
+
+	size, err := io.Copy(localFile, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write local file: %w", err)
+	}
+	if err := localFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write local file: %w", err)
+	}
+
+	return &model.DownloadedFile{Path: localPath, SizeBytes: size}, nil
+}
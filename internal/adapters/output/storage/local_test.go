@@ -0,0 +1,100 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage_Upload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write the object under the root", func(t *testing.T) {
+		root := t.TempDir()
+		local := storage.NewLocalStorage(root, time.Hour)
+
+		require.NoError(t, local.UploadStream(ctx, "output/job-123.zip", strings.NewReader("zip content")))
+
+		content, err := os.ReadFile(filepath.Join(root, "output", "job-123.zip"))
+		require.NoError(t, err)
+		assert.Equal(t, "zip content", string(content))
+		entries, err := os.ReadDir(filepath.Join(root, "output"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "the temporary file must be renamed into place")
+	})
+
+	t.Run("should copy a local file", func(t *testing.T) {
+		root := t.TempDir()
+		local := storage.NewLocalStorage(root, time.Hour)
+		source := filepath.Join(t.TempDir(), "video.mp4")
+		require.NoError(t, os.WriteFile(source, []byte("video"), 0o644))
+
+		require.NoError(t, local.UploadFile(ctx, source, "uploads/video.mp4"))
+
+		content, err := os.ReadFile(filepath.Join(root, "uploads", "video.mp4"))
+		require.NoError(t, err)
+		assert.Equal(t, "video", string(content))
+	})
+
+	t.Run("should reject keys that escape the root", func(t *testing.T) {
+		local := storage.NewLocalStorage(t.TempDir(), time.Hour)
+
+		err := local.UploadStream(ctx, "../outside.zip", strings.NewReader("zip"))
+		assert.ErrorContains(t, err, "invalid object key")
+	})
+}
+
+func TestLocalStorage_DownloadFile(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should copy the object into the workspace", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "uploads"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "uploads", "video.mp4"), []byte("video"), 0o644))
+		local := storage.NewLocalStorage(root, time.Hour)
+		workspace := &model.Workspace{JobID: "job-123", Dir: t.TempDir()}
+
+		file, err := local.DownloadFile(ctx, "uploads/video.mp4", workspace)
+		require.NoError(t, err)
+
+		assert.Equal(t, workspace.Path("input.mp4"), file.Path)
+		assert.EqualValues(t, 5, file.SizeBytes)
+		content, err := os.ReadFile(file.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "video", string(content))
+	})
+
+	t.Run("should return ErrObjectNotFound for a missing object", func(t *testing.T) {
+		local := storage.NewLocalStorage(t.TempDir(), time.Hour)
+		workspace := &model.Workspace{JobID: "job-123", Dir: t.TempDir()}
+
+		_, err := local.DownloadFile(ctx, "uploads/missing.mp4", workspace)
+		assert.ErrorIs(t, err, model.ErrObjectNotFound)
+	})
+}
+
+func TestLocalStorage_PresignDownload(t *testing.T) {
+	root := t.TempDir()
+	local := storage.NewLocalStorage(root, time.Hour)
+
+	link, err := local.PresignDownload(context.Background(), "output/job-123.zip")
+	require.NoError(t, err)
+
+	assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(root, "output", "job-123.zip")), link.URL)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), link.ExpiresAt, time.Minute)
+}
+
+func TestLocalStorage_Ping(t *testing.T) {
+	root := t.TempDir()
+
+	assert.NoError(t, storage.NewLocalStorage(root, time.Hour).Ping(context.Background()))
+	assert.Error(t, storage.NewLocalStorage(filepath.Join(root, "missing"), time.Hour).Ping(context.Background()))
+}
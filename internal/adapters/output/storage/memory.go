@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// MemoryStorage keeps objects in memory, for running the worker and its
+// tests without S3.
+type MemoryStorage struct {
+	mu         sync.RWMutex
+	objects    map[string][]byte
+	linkExpiry time.Duration
+}
+
+// NewMemoryStorage returns an empty store. Download links are memory URLs
+// reported as valid for linkExpiry, like a presigned S3 URL.
+func NewMemoryStorage(linkExpiry time.Duration) *MemoryStorage {
+	return &MemoryStorage{objects: map[string][]byte{}, linkExpiry: linkExpiry}
+}
+
+// Put stores content under objectKey, replacing any previous object.
+func (m *MemoryStorage) Put(objectKey string, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[objectKey] = bytes.Clone(content)
+}
+
+// Get returns the content stored under objectKey.
+func (m *MemoryStorage) Get(objectKey string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	content, ok := m.objects[objectKey]
+	return bytes.Clone(content), ok
+}
+
+// Ping always succeeds: the objects live in the worker's own memory.
+func (m *MemoryStorage) Ping(_ context.Context) error {
+	return nil
+}
+
+func (m *MemoryStorage) DownloadFile(_ context.Context, objectKey string, workspace *model.Workspace) (*model.DownloadedFile, error) {
+	content, ok := m.Get(objectKey)
+	if !ok {
+		return nil, fmt.Errorf("object '%s' does not exist in memory: %w", objectKey, model.ErrObjectNotFound)
+	}
+	return copyToWorkspace(objectKey, bytes.NewReader(content), workspace)
+}
+
+func (m *MemoryStorage) UploadFile(ctx context.Context, localFilePath, objectKey string) error {
+	file, err := os.Open(localFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file '%s' for upload: %w", localFilePath, err)
+	}
+	defer file.Close()
+
+	return m.UploadStream(ctx, objectKey, file)
+}
+
+func (m *MemoryStorage) UploadStream(_ context.Context, objectKey string, body io.Reader) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read object '%s': %w", objectKey, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[objectKey] = content
+	return nil
+}
+
+// PresignDownload returns a memory URL naming the object. It can't be
+// fetched from outside the worker, but it tells which object a job produced.
+func (m *MemoryStorage) PresignDownload(_ context.Context, objectKey string) (*model.DownloadLink, error) {
+	link := url.URL{Scheme: "memory", Path: "/" + objectKey}
+	return &model.DownloadLink{URL: link.String(), ExpiresAt: time.Now().Add(m.linkExpiry)}, nil
+}
@@ -0,0 +1,60 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should download what was put", func(t *testing.T) {
+		memory := storage.NewMemoryStorage(time.Hour)
+		memory.Put("uploads/video.mp4", []byte("video"))
+		workspace := &model.Workspace{JobID: "job-123", Dir: t.TempDir()}
+
+		file, err := memory.DownloadFile(ctx, "uploads/video.mp4", workspace)
+		require.NoError(t, err)
+
+		assert.EqualValues(t, 5, file.SizeBytes)
+		content, err := os.ReadFile(file.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "video", string(content))
+	})
+
+	t.Run("should keep uploaded streams", func(t *testing.T) {
+		memory := storage.NewMemoryStorage(time.Hour)
+
+		require.NoError(t, memory.UploadStream(ctx, "output/job-123.zip", strings.NewReader("zip content")))
+
+		content, ok := memory.Get("output/job-123.zip")
+		require.True(t, ok)
+		assert.Equal(t, "zip content", string(content))
+	})
+
+	t.Run("should return ErrObjectNotFound for a missing object", func(t *testing.T) {
+		memory := storage.NewMemoryStorage(time.Hour)
+		workspace := &model.Workspace{JobID: "job-123", Dir: t.TempDir()}
+
+		_, err := memory.DownloadFile(ctx, "uploads/missing.mp4", workspace)
+		assert.ErrorIs(t, err, model.ErrObjectNotFound)
+	})
+
+	t.Run("should name the object in its download link", func(t *testing.T) {
+		memory := storage.NewMemoryStorage(time.Hour)
+
+		link, err := memory.PresignDownload(ctx, "output/job-123.zip")
+		require.NoError(t, err)
+
+		assert.Equal(t, "memory:///output/job-123.zip", link.URL)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), link.ExpiresAt, time.Minute)
+	})
+}
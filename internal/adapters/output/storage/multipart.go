@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// minPartSize is the smallest part S3 accepts, except for the last one.
+	minPartSize        = 5 << 20
+	defaultPartSize    = 16 << 20
+	defaultConcurrency = 4
+	// maxPresignExpiry is the longest a SigV4 presigned URL stays valid.
+	maxPresignExpiry     = 7 * 24 * time.Hour
+	defaultPresignExpiry = 24 * time.Hour
+	uploadContentType    = "application/octet-stream"
+)
+
+type S3Options struct {
+	// PartSize is the size of each multipart upload part and of each range
+	// requested when downloading. Memory use while uploading is about
+	// PartSize * (Concurrency + 1); downloads write straight to disk.
+	PartSize int64
+	// Concurrency is how many parts are transferred at the same time.
+	Concurrency int
+	// PresignExpiry is how long download links stay valid, up to seven days.
+	PresignExpiry time.Duration
+}
+
+func (o S3Options) withDefaults() S3Options {
+	if o.PartSize == 0 {
+		o.PartSize = defaultPartSize
+	}
+	o.PartSize = max(o.PartSize, minPartSize)
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	if o.PresignExpiry <= 0 {
+		o.PresignExpiry = defaultPresignExpiry
+	}
+	o.PresignExpiry = min(o.PresignExpiry, maxPresignExpiry)
+	return o
+}
+
+// UploadStream uploads everything read from body. Content that fits in one
+// part is sent with a single PutObject; anything larger goes through a
+// multipart upload, which is aborted if any part fails so no orphaned parts
+// keep being billed.
+func (a *S3Client) UploadStream(ctx context.Context, objectKey string, body io.Reader) error {
+	first, err := readPart(body, a.opts.PartSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read content for '%s': %w", objectKey, err)
+	}
+	if errors.Is(err, io.EOF) || int64(len(first)) < a.opts.PartSize {
+		return a.putObject(ctx, objectKey, first)
+	}
+
+	created, err := a.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(a.bucketName),
+		Key:         aws.String(objectKey),
+		ContentType: aws.String(uploadContentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload of '%s': %w", objectKey, err)
+	}
+
+	parts, err := a.uploadParts(ctx, objectKey, created.UploadId, first, body)
+	if err == nil {
+		_, err = a.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(a.bucketName),
+			Key:             aws.String(objectKey),
+			UploadId:        created.UploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to complete multipart upload of '%s': %w", objectKey, err)
+		}
+	}
+	if err != nil {
+		return errors.Join(err, a.abortUpload(ctx, objectKey, created.UploadId))
+	}
+
+	return nil
+}
+
+func (a *S3Client) putObject(ctx context.Context, objectKey string, content []byte) error {
+	_, err := a.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(a.bucketName),
+		Key:           aws.String(objectKey),
+		Body:          bytes.NewReader(content),
+		ContentLength: aws.Int64(int64(len(content))),
+		ContentType:   aws.String(uploadContentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object '%s' to S3: %w", objectKey, err)
+	}
+	return nil
+}
+
+// uploadParts uploads first and then every part read from body, at most
+// Concurrency at a time, and returns them ordered by part number.
+func (a *S3Client) uploadParts(ctx context.Context, objectKey string, uploadID *string, first []byte, body io.Reader) ([]types.CompletedPart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		parts    []types.CompletedPart
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	slots := make(chan struct{}, a.opts.Concurrency)
+	part := first
+	for partNumber := int32(1); ; partNumber++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			setErr(err)
+			break
+		}
+
+		wg.Add(1)
+		go func(partNumber int32, content []byte) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			out, err := a.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:        aws.String(a.bucketName),
+				Key:           aws.String(objectKey),
+				UploadId:      uploadID,
+				PartNumber:    aws.Int32(partNumber),
+				Body:          bytes.NewReader(content),
+				ContentLength: aws.Int64(int64(len(content))),
+			})
+			if err != nil {
+				setErr(fmt.Errorf("failed to upload part %d of '%s': %w", partNumber, objectKey, err))
+				return
+			}
+
+			mu.Lock()
+			parts = append(parts, types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(partNumber)})
+			mu.Unlock()
+		}(partNumber, part)
+
+		next, err := readPart(body, a.opts.PartSize)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			setErr(fmt.Errorf("failed to read content for '%s': %w", objectKey, err))
+			break
+		}
+		part = next
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	slices.SortFunc(parts, func(a, b types.CompletedPart) int {
+		return int(aws.ToInt32(a.PartNumber) - aws.ToInt32(b.PartNumber))
+	})
+	return parts, nil
+}
+
+// abortUpload discards the parts uploaded so far. It runs even when ctx was
+// cancelled, since that is usually why the upload failed.
+func (a *S3Client) abortUpload(ctx context.Context, objectKey string, uploadID *string) error {
+	_, err := a.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(a.bucketName),
+		Key:      aws.String(objectKey),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload of '%s': %w", objectKey, err)
+	}
+	return nil
+}
+
+// readPart reads up to size bytes. It returns io.EOF only when nothing was
+// left to read.
+func readPart(body io.Reader, size int64) ([]byte, error) {
+	part := make([]byte, size)
+	n, err := io.ReadFull(body, part)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = nil
+	}
+	return part[:n], err
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// PresignDownload signs a GET request for the object, so whoever holds the
+// link can download it without AWS credentials until it expires.
+func (a *S3Client) PresignDownload(ctx context.Context, objectKey string) (*model.DownloadLink, error) {
+	signedAt := time.Now()
+	req, err := a.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(a.bucketName),
+		Key:    aws.String(objectKey),
+	}, s3.WithPresignExpires(a.opts.PresignExpiry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign download of '%s': %w", objectKey, err)
+	}
+	return &model.DownloadLink{URL: req.URL, ExpiresAt: signedAt.Add(a.opts.PresignExpiry)}, nil
+}
@@ -2,80 +2,90 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
 )
 
 type S3Client struct {
 	client     ports.S3Client
+	presigner  ports.S3Presigner
 	bucketName string
+	opts       S3Options
 }
 
-func NewS3Adapter(s3Client ports.S3Client, bucketName string) *S3Client {
+func NewS3Adapter(s3Client ports.S3Client, presigner ports.S3Presigner, bucketName string, opts S3Options) *S3Client {
 	return &S3Client{
 		client:     s3Client,
+		presigner:  presigner,
 		bucketName: bucketName,
+		opts:       opts.withDefaults(),
 	}
 }
 
-func (a *S3Client) DownloadFile(ctx context.Context, objectKey string) (*model.DownloadedFile, error) {
-	input := &s3.GetObjectInput{
-		Bucket: aws.String(a.bucketName),
-		Key:    aws.String(objectKey),
+// Ping checks that the bucket exists and can be reached with the worker's
+// credentials.
+func (a *S3Client) Ping(ctx context.Context) error {
+	_, err := a.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(a.bucketName)})
+	if err != nil {
+		return fmt.Errorf("failed to reach bucket '%s': %w", a.bucketName, err)
 	}
+	return nil
+}
 
-	result, err := a.client.GetObject(ctx, input)
+// DownloadFile copies the object into the job's workspace. Large objects are
+// fetched as parallel ranged requests, and the file is checked against the
+// object's checksum or ETag before it is handed over.
+func (a *S3Client) DownloadFile(ctx context.Context, objectKey string, workspace *model.Workspace) (*model.DownloadedFile, error) {
+	head, err := a.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(a.bucketName),
+		Key:          aws.String(objectKey),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
 	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		var notFound *types.NotFound
+		if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+			return nil, fmt.Errorf("object '%s' does not exist in S3: %w", objectKey, model.ErrObjectNotFound)
+		}
 		return nil, fmt.Errorf("failed to get object '%s' from S3: %w", objectKey, err)
 	}
 
-	tempFile, err := os.CreateTemp("", "video-*.tmp")
+	localPath := workspace.Path("input" + filepath.Ext(objectKey))
+	localFile, err := os.Create(localPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+		return nil, fmt.Errorf("failed to create local file: %w", err)
 	}
+	defer localFile.Close()
 
-	if _, err := io.Copy(tempFile, result.Body); err != nil {
-		return nil, fmt.Errorf("failed to copy S3 content: %w", err)
+	if err := a.downloadRanges(ctx, objectKey, head, localFile); err != nil {
+		return nil, err
 	}
-
-	downloaded := &model.DownloadedFile{
-		Path: tempFile.Name(),
-		File: tempFile,
+	if err := localFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write local file: %w", err)
+	}
+	if err := a.verifyDownload(ctx, objectKey, head, localPath); err != nil {
+		return nil, err
 	}
 
-	return downloaded, nil
+	return &model.DownloadedFile{Path: localPath, SizeBytes: aws.ToInt64(head.ContentLength)}, nil
 }
 
+// UploadFile uploads a local file, using a multipart upload when it is larger
+// than one part.
 func (a *S3Client) UploadFile(ctx context.Context, localFilePath, objectKey string) error {
-
 	file, err := os.Open(localFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to open local file '%s' for upload: %w", localFilePath, err)
 	}
+	defer file.Close()
 
-	stat, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to get file info for '%s': %w", localFilePath, err)
-	}
-
-	input := &s3.PutObjectInput{
-		Bucket:        aws.String(a.bucketName),
-		Key:           aws.String(objectKey),
-		Body:          file,
-		ContentLength: aws.Int64(stat.Size()),
-		ContentType:   aws.String("application/octet-stream"),
-	}
-
-	_, err = a.client.PutObject(ctx, input)
-	if err != nil {
-		return fmt.Errorf("failed to upload object '%s' to S3: %w", objectKey, err)
-	}
-
-	return nil
+	return a.UploadStream(ctx, objectKey, file)
 }
@@ -3,31 +3,55 @@ package storage_test
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
-	"strings"
+	"path/filepath"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 )
 
+const partSize = 5 << 20
+
 type s3TestSuite struct {
 	suite.Suite
 
-	ctx          context.Context
-	mockS3Client *mocks.MockS3Client
-	s3Adapter    *storage.S3Client
+	ctx           context.Context
+	mockS3Client  *mocks.MockS3Client
+	mockPresigner *mocks.MockS3Presigner
+	s3Adapter     *storage.S3Client
 }
 
 func (suite *s3TestSuite) SetupTest() {
 	ctrl := gomock.NewController(suite.T())
 	suite.ctx = context.Background()
 	suite.mockS3Client = mocks.NewMockS3Client(ctrl)
-	suite.s3Adapter = storage.NewS3Adapter(suite.mockS3Client, "bucket-videos")
+	suite.mockPresigner = mocks.NewMockS3Presigner(ctrl)
+	suite.s3Adapter = storage.NewS3Adapter(suite.mockS3Client, suite.mockPresigner, "bucket-videos", storage.S3Options{
+		PartSize:      partSize,
+		Concurrency:   2,
+		PresignExpiry: 2 * time.Hour,
+	})
 }
 
 func (suite *s3TestSuite) AfterTest(_, _ string) {
@@ -45,51 +69,260 @@ func (suite *s3TestSuite) Test_DownloadFile() {
 
 	st.Run("should download file successfully", func(t *testing.T) {
 		objectKey := "video.mp4"
+		content := []byte("conteudo do arquivo")
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{
+				ContentLength: aws.Int64(int64(len(content))),
+				ETag:          aws.String(`"` + md5Hex(content) + `"`),
+			}, nil)
+		suite.mockS3Client.EXPECT().
+			GetObject(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+				suite.Equal("bytes=0-18", aws.ToString(input.Range))
+				suite.Equal(`"`+md5Hex(content)+`"`, aws.ToString(input.IfMatch))
+				return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(content))}, nil
+			})
+
+		workspace := &model.Workspace{JobID: "job-123", Dir: t.TempDir()}
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey, workspace)
+		suite.NoError(err)
+		suite.Equal(filepath.Join(workspace.Dir, "input.mp4"), downloadedFile.Path)
+		downloaded, err := os.ReadFile(downloadedFile.Path)
+		suite.NoError(err)
+		suite.Equal("conteudo do arquivo", string(downloaded))
+	})
+
+	st.Run("should download large objects in parallel ranges", func(t *testing.T) {
+		content := randomContent(2*partSize + partSize/2)
+		var (
+			mu     sync.Mutex
+			ranges []string
+		)
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{
+				ContentLength:  aws.Int64(int64(len(content))),
+				ChecksumSHA256: aws.String(sha256Base64(content)),
+			}, nil)
+		suite.mockS3Client.EXPECT().
+			GetObject(gomock.Any(), gomock.Any()).
+			Times(3).
+			DoAndReturn(func(ctx context.Context, input *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+				mu.Lock()
+				ranges = append(ranges, aws.ToString(input.Range))
+				mu.Unlock()
+				return serveRange(content)(ctx, input, opts...)
+			})
+
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, "video.mp4", &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
+		suite.NoError(err)
+		downloaded, err := os.ReadFile(downloadedFile.Path)
+		suite.NoError(err)
+		suite.Equal(content, downloaded)
+		suite.ElementsMatch([]string{
+			fmt.Sprintf("bytes=0-%d", partSize-1),
+			fmt.Sprintf("bytes=%d-%d", partSize, 2*partSize-1),
+			fmt.Sprintf("bytes=%d-%d", 2*partSize, len(content)-1),
+		}, ranges)
+	})
+
+	st.Run("should resume from the last byte when the connection drops", func(t *testing.T) {
+		content := []byte("conteudo do arquivo")
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{
+				ContentLength: aws.Int64(int64(len(content))),
+				ChecksumCRC32: aws.String(crc32Base64(content)),
+			}, nil)
+		gomock.InOrder(
+			suite.mockS3Client.EXPECT().
+				GetObject(gomock.Any(), gomock.Any()).
+				Return(&s3.GetObjectOutput{
+					Body: io.NopCloser(io.MultiReader(bytes.NewReader(content[:8]), &failingReader{err: syscall.ECONNRESET})),
+				}, nil),
+			suite.mockS3Client.EXPECT().
+				GetObject(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, input *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+					suite.Equal("bytes=8-18", aws.ToString(input.Range))
+					return serveRange(content)(ctx, input, opts...)
+				}),
+		)
+
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, "video.mp4", &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
+		suite.NoError(err)
+		downloaded, err := os.ReadFile(downloadedFile.Path)
+		suite.NoError(err)
+		suite.Equal(content, downloaded)
+	})
+
+	st.Run("should return ErrCorruptDownload when the checksum does not match", func(t *testing.T) {
+		content := []byte("conteudo do arquivo")
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{
+				ContentLength:  aws.Int64(int64(len(content))),
+				ChecksumSHA256: aws.String(sha256Base64([]byte("outro conteudo"))),
+			}, nil)
+		suite.mockS3Client.EXPECT().
+			GetObject(gomock.Any(), gomock.Any()).
+			DoAndReturn(serveRange(content))
+
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, "video.mp4", &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
+		suite.ErrorIs(err, model.ErrCorruptDownload)
+		suite.Nil(downloadedFile)
+	})
 
+	st.Run("should verify the ETag of multipart uploads", func(t *testing.T) {
+		content := randomContent(partSize + 10)
+		partSums := append(md5Sum(content[:partSize]), md5Sum(content[partSize:])...)
+		etag := fmt.Sprintf(`"%s-2"`, md5Hex(partSums))
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ context.Context, input *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+				if input.PartNumber != nil {
+					return &s3.HeadObjectOutput{ContentLength: aws.Int64(partSize), PartsCount: aws.Int32(2)}, nil
+				}
+				return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(content))), ETag: aws.String(etag)}, nil
+			})
 		suite.mockS3Client.EXPECT().
 			GetObject(gomock.Any(), gomock.Any()).
-			Return(&s3.GetObjectOutput{
-				Body: io.NopCloser(bytes.NewReader([]byte("conteudo do arquivo"))),
+			Times(2).
+			DoAndReturn(serveRange(content))
+
+		_, err := suite.s3Adapter.DownloadFile(suite.ctx, "video.mp4", &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
+		suite.NoError(err)
+	})
+
+	st.Run("should not compare the ETag of KMS encrypted objects", func(t *testing.T) {
+		content := []byte("conteudo do arquivo")
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{
+				ContentLength:        aws.Int64(int64(len(content))),
+				ETag:                 aws.String(`"` + md5Hex([]byte("outro conteudo")) + `"`),
+				ServerSideEncryption: types.ServerSideEncryptionAwsKms,
 			}, nil)
+		suite.mockS3Client.EXPECT().
+			GetObject(gomock.Any(), gomock.Any()).
+			DoAndReturn(serveRange(content))
 
-		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey)
-		tempDir := os.TempDir()
-		suite.True(strings.HasPrefix(downloadedFile.Path, tempDir))
+		_, err := suite.s3Adapter.DownloadFile(suite.ctx, "video.mp4", &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
 		suite.NoError(err)
-		suite.NotEmpty(downloadedFile.Path)
-		suite.Contains(downloadedFile.Path, "video-")
-		suite.Contains(downloadedFile.Path, ".tmp")
 	})
 
 	st.Run("should return error when S3 GetObject fails", func(t *testing.T) {
 		objectKey := "video.mp4"
 
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(19)}, nil)
 		suite.mockS3Client.EXPECT().
 			GetObject(gomock.Any(), gomock.Any()).
 			Return(nil, io.ErrUnexpectedEOF)
 
-		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey)
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey, &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
 		suite.Error(err)
 		suite.Nil(downloadedFile)
 	})
 
+	st.Run("should return ErrObjectNotFound when the object does not exist", func(t *testing.T) {
+		objectKey := "missing.mp4"
+
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(nil, &types.NotFound{})
+
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey, &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
+		suite.ErrorIs(err, model.ErrObjectNotFound)
+		suite.Nil(downloadedFile)
+	})
+
 	st.Run("Should return error when failed to copy S3 content", func(t *testing.T) {
 		objectKey := "video.mp4"
 
-		errorReader := io.NopCloser(&errorOnRead{})
-
+		suite.mockS3Client.EXPECT().
+			HeadObject(gomock.Any(), gomock.Any()).
+			Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(19)}, nil)
 		suite.mockS3Client.EXPECT().
 			GetObject(gomock.Any(), gomock.Any()).
-			Return(&s3.GetObjectOutput{
-				Body: errorReader,
-			}, nil)
+			Times(4).
+			DoAndReturn(func(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+				return &s3.GetObjectOutput{Body: io.NopCloser(&errorOnRead{})}, nil
+			})
 
-		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey)
-		suite.Error(err)
+		downloadedFile, err := suite.s3Adapter.DownloadFile(suite.ctx, objectKey, &model.Workspace{JobID: "job-123", Dir: t.TempDir()})
+		suite.ErrorIs(err, io.ErrUnexpectedEOF)
 		suite.Nil(downloadedFile)
 	})
 }
 
+func (suite *s3TestSuite) Test_Ping() {
+	st := suite.T()
+
+	st.Run("should reach the bucket", func(t *testing.T) {
+		suite.mockS3Client.EXPECT().
+			HeadBucket(gomock.Any(), &s3.HeadBucketInput{Bucket: aws.String("bucket-videos")}).
+			Return(&s3.HeadBucketOutput{}, nil)
+
+		suite.NoError(suite.s3Adapter.Ping(suite.ctx))
+	})
+
+	st.Run("should return error when the bucket can't be reached", func(t *testing.T) {
+		suite.mockS3Client.EXPECT().
+			HeadBucket(gomock.Any(), gomock.Any()).
+			Return(nil, &types.NotFound{})
+
+		err := suite.s3Adapter.Ping(suite.ctx)
+		suite.ErrorContains(err, "failed to reach bucket 'bucket-videos'")
+	})
+}
+
+func (suite *s3TestSuite) Test_PresignDownload() {
+	s := suite.T()
+
+	s.Run("Should sign a GET for the object with the configured expiry", func(t *testing.T) {
+		const signedURL = "https://bucket-videos.s3.amazonaws.com/output/job-123.zip?X-Amz-Expires=7200&X-Amz-Signature=abc"
+		suite.mockPresigner.EXPECT().
+			PresignGetObject(suite.ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+				suite.Equal("bucket-videos", aws.ToString(input.Bucket))
+				suite.Equal("output/job-123.zip", aws.ToString(input.Key))
+				var opts s3.PresignOptions
+				for _, fn := range optFns {
+					fn(&opts)
+				}
+				suite.Equal(2*time.Hour, opts.Expires)
+				return &v4.PresignedHTTPRequest{URL: signedURL, Method: "GET"}, nil
+			})
+
+		before := time.Now()
+		link, err := suite.s3Adapter.PresignDownload(suite.ctx, "output/job-123.zip")
+
+		suite.Require().NoError(err)
+		suite.Equal(signedURL, link.URL)
+		suite.WithinRange(link.ExpiresAt, before.Add(2*time.Hour), time.Now().Add(2*time.Hour))
+	})
+
+	s.Run("Should return error when signing fails", func(t *testing.T) {
+		suite.mockPresigner.EXPECT().
+			PresignGetObject(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("no credentials"))
+
+		link, err := suite.s3Adapter.PresignDownload(suite.ctx, "output/job-123.zip")
+
+		suite.Nil(link)
+		suite.ErrorContains(err, "failed to presign download of 'output/job-123.zip'")
+	})
+}
+
 func (suite *s3TestSuite) Test_UploadFile() {
 	st := suite.T()
 
@@ -134,6 +367,138 @@ func (suite *s3TestSuite) Test_UploadFile() {
 	})
 }
 
+func (suite *s3TestSuite) Test_UploadStream() {
+	st := suite.T()
+
+	st.Run("should upload content smaller than a part with PutObject", func(t *testing.T) {
+		suite.mockS3Client.EXPECT().
+			PutObject(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+				suite.Equal("output/job-123.zip", aws.ToString(input.Key))
+				suite.Equal(int64(len("conteudo do zip")), aws.ToInt64(input.ContentLength))
+				return &s3.PutObjectOutput{}, nil
+			})
+
+		err := suite.s3Adapter.UploadStream(suite.ctx, "output/job-123.zip", bytes.NewReader([]byte("conteudo do zip")))
+		suite.NoError(err)
+	})
+
+	st.Run("should upload large content in parts and complete them in order", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 2*partSize+partSize/2)
+		var (
+			mu       sync.Mutex
+			received = map[int32]int{}
+		)
+
+		suite.mockS3Client.EXPECT().
+			CreateMultipartUpload(gomock.Any(), gomock.Any()).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		suite.mockS3Client.EXPECT().
+			UploadPart(gomock.Any(), gomock.Any()).
+			Times(3).
+			DoAndReturn(func(_ context.Context, input *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				received[aws.ToInt32(input.PartNumber)] = int(aws.ToInt64(input.ContentLength))
+				return &s3.UploadPartOutput{ETag: aws.String("etag")}, nil
+			})
+		suite.mockS3Client.EXPECT().
+			CompleteMultipartUpload(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, input *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+				suite.Equal("upload-1", aws.ToString(input.UploadId))
+				suite.Len(input.MultipartUpload.Parts, 3)
+				for i, part := range input.MultipartUpload.Parts {
+					suite.Equal(int32(i+1), aws.ToInt32(part.PartNumber))
+				}
+				return &s3.CompleteMultipartUploadOutput{}, nil
+			})
+
+		err := suite.s3Adapter.UploadStream(suite.ctx, "output/job-123.zip", bytes.NewReader(content))
+		suite.NoError(err)
+		suite.Equal(map[int32]int{1: partSize, 2: partSize, 3: partSize / 2}, received)
+	})
+
+	st.Run("should abort the multipart upload when a part fails", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 2*partSize)
+
+		suite.mockS3Client.EXPECT().
+			CreateMultipartUpload(gomock.Any(), gomock.Any()).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		suite.mockS3Client.EXPECT().
+			UploadPart(gomock.Any(), gomock.Any()).
+			MinTimes(1).
+			Return(nil, io.ErrUnexpectedEOF)
+		suite.mockS3Client.EXPECT().
+			AbortMultipartUpload(gomock.Any(), gomock.Any()).
+			Return(&s3.AbortMultipartUploadOutput{}, nil)
+
+		err := suite.s3Adapter.UploadStream(suite.ctx, "output/job-123.zip", bytes.NewReader(content))
+		suite.ErrorIs(err, io.ErrUnexpectedEOF)
+	})
+
+	st.Run("should abort the multipart upload when reading the content fails", func(t *testing.T) {
+		readErr := errors.New("ffmpeg crashed")
+		content := io.MultiReader(bytes.NewReader(bytes.Repeat([]byte("a"), partSize)), &failingReader{err: readErr})
+
+		suite.mockS3Client.EXPECT().
+			CreateMultipartUpload(gomock.Any(), gomock.Any()).
+			Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil)
+		suite.mockS3Client.EXPECT().
+			UploadPart(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(&s3.UploadPartOutput{ETag: aws.String("etag")}, nil)
+		suite.mockS3Client.EXPECT().
+			AbortMultipartUpload(gomock.Any(), gomock.Any()).
+			Return(&s3.AbortMultipartUploadOutput{}, nil)
+
+		err := suite.s3Adapter.UploadStream(suite.ctx, "output/job-123.zip", content)
+		suite.ErrorIs(err, readErr)
+	})
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	return 0, f.err
+}
+
+// serveRange answers ranged GetObject requests from content.
+func serveRange(content []byte) func(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return func(_ context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+		var start, end int
+		if _, err := fmt.Sscanf(aws.ToString(input.Range), "bytes=%d-%d", &start, &end); err != nil {
+			return nil, err
+		}
+		return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(content[start : end+1]))}, nil
+	}
+}
+
+func randomContent(size int) []byte {
+	content := make([]byte, size)
+	_, _ = rand.Read(content)
+	return content
+}
+
+func md5Sum(content []byte) []byte {
+	sum := md5.Sum(content)
+	return sum[:]
+}
+
+func md5Hex(content []byte) string {
+	return hex.EncodeToString(md5Sum(content))
+}
+
+func sha256Base64(content []byte) string {
+	sum := sha256.Sum256(content)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func crc32Base64(content []byte) string {
+	return base64.StdEncoding.EncodeToString(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(content)))
+}
+
 type errorOnRead struct{}
 
 func (e *errorOnRead) Read(p []byte) (n int, err error) {
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	model "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+)
+
+// crc64NVME is the reflected CRC-64/NVME polynomial S3 uses by default.
+var crc64NVME = crc64.MakeTable(0x9a6c9329ac4bc9b5)
+
+// verifyDownload checks the downloaded file against the full-object checksum
+// stored with the object or, failing that, against its ETag. Objects with
+// neither are accepted with a warning.
+func (a *S3Client) verifyDownload(ctx context.Context, objectKey string, head *s3.HeadObjectOutput, localPath string) error {
+	if name, expected, newHash, ok := fullObjectChecksum(head); ok {
+		actual, err := checksumFile(localPath, newHash)
+		if err != nil {
+			return err
+		}
+		if got := base64.StdEncoding.EncodeToString(actual); got != expected {
+			return fmt.Errorf("%s of '%s' is %s, expected %s: %w", name, objectKey, got, expected, model.ErrCorruptDownload)
+		}
+		return nil
+	}
+
+	etag := strings.Trim(aws.ToString(head.ETag), `"`)
+	if etag == "" || !etagIsMD5(head) {
+		logger.FromContext(ctx).Warn("Object has no checksum to verify the download against.", "key", objectKey)
+		return nil
+	}
+
+	got, err := a.computeETag(ctx, objectKey, localPath, etag)
+	if err != nil {
+		return err
+	}
+	if got != etag {
+		return fmt.Errorf("ETag of '%s' is %s, expected %s: %w", objectKey, got, etag, model.ErrCorruptDownload)
+	}
+	return nil
+}
+
+// fullObjectChecksum returns the strongest checksum S3 has for the whole
+// object. Composite checksums of multipart uploads are checksums of the part
+// checksums and can't be compared with a hash of the file.
+func fullObjectChecksum(head *s3.HeadObjectOutput) (string, string, func() hash.Hash, bool) {
+	if head.ChecksumType == types.ChecksumTypeComposite {
+		return "", "", nil, false
+	}
+	checksums := []struct {
+		name    string
+		value   *string
+		newHash func() hash.Hash
+	}{
+		{"SHA-256", head.ChecksumSHA256, sha256.New},
+		{"SHA-1", head.ChecksumSHA1, sha1.New},
+		{"CRC-64/NVME", head.ChecksumCRC64NVME, func() hash.Hash { return crc64.New(crc64NVME) }},
+		{"CRC-32C", head.ChecksumCRC32C, func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+		{"CRC-32", head.ChecksumCRC32, func() hash.Hash { return crc32.NewIEEE() }},
+	}
+	for _, c := range checksums {
+		if value := aws.ToString(c.value); value != "" && !strings.Contains(value, "-") {
+			return c.name, value, c.newHash, true
+		}
+	}
+	return "", "", nil, false
+}
+
+// etagIsMD5 reports whether the ETag is derived from MD5 hashes of the
+// content, which isn't the case for objects encrypted with KMS or SSE-C.
+func etagIsMD5(head *s3.HeadObjectOutput) bool {
+	switch head.ServerSideEncryption {
+	case types.ServerSideEncryptionAwsKms, types.ServerSideEncryptionAwsKmsDsse:
+		return false
+	}
+	return head.SSECustomerAlgorithm == nil
+}
+
+// computeETag rebuilds the ETag S3 gives the file's content. A multipart ETag
+// is the MD5 of the parts' MD5s followed by the part count, so the part size
+// is looked up from the first part.
+func (a *S3Client) computeETag(ctx context.Context, objectKey, localPath, etag string) (string, error) {
+	_, count, multipart := strings.Cut(etag, "-")
+	if !multipart {
+		sum, err := checksumFile(localPath, md5.New)
+		return hex.EncodeToString(sum), err
+	}
+
+	parts, err := strconv.Atoi(count)
+	if err != nil {
+		return "", fmt.Errorf("invalid multipart ETag '%s' for '%s'", etag, objectKey)
+	}
+	firstPart, err := a.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:     aws.String(a.bucketName),
+		Key:        aws.String(objectKey),
+		PartNumber: aws.Int32(1),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get part size of '%s' from S3: %w", objectKey, err)
+	}
+	partSize := aws.ToInt64(firstPart.ContentLength)
+	if partSize <= 0 {
+		return "", fmt.Errorf("invalid part size %d for '%s'", partSize, objectKey)
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	partSums := md5.New()
+	for range parts {
+		part := md5.New()
+		if _, err := io.CopyN(part, file, partSize); err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read local file: %w", err)
+		}
+		partSums.Write(part.Sum(nil))
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(partSums.Sum(nil)), parts), nil
+}
+
+func checksumFile(localPath string, newHash func() hash.Hash) ([]byte, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("failed to read local file: %w", err)
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,67 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+// manager keeps job workspaces as directories under the worker's own
+// subdirectory of root. Workers sharing a host or volume never see each
+// other's workspaces, so whatever is left there at startup was abandoned by a
+// previous run of the same worker.
+type manager struct {
+	root string
+}
+
+func NewManager(root, workerID string) *manager {
+	return &manager{root: filepath.Join(root, workerID)}
+}
+
+// Create makes a new workspace for an attempt of jobID. Every attempt gets
+// its own directory, so a redelivered message never touches the files of one
+// that is still running.
+func (m *manager) Create(jobID string) (*domain.Workspace, error) {
+	if err := os.MkdirAll(m.root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace root '%s': %w", m.root, err)
+	}
+
+	dir, err := os.MkdirTemp(m.root, jobID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace for job '%s': %w", jobID, err)
+	}
+
+	return &domain.Workspace{JobID: jobID, Dir: dir}, nil
+}
+
+func (m *manager) Remove(workspace *domain.Workspace) error {
+	if err := os.RemoveAll(workspace.Dir); err != nil {
+		return fmt.Errorf("failed to remove workspace '%s': %w", workspace.Dir, err)
+	}
+	return nil
+}
+
+// Sweep deletes every workspace left by crashed runs of this worker, leaving
+// those of other workers alone. It must run before the worker starts
+// processing jobs and returns how many workspaces were removed.
+func (m *manager) Sweep() (int, error) {
+	entries, err := os.ReadDir(m.root)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workspaces in '%s': %w", m.root, err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		path := filepath.Join(m.root, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("failed to remove orphaned workspace '%s': %w", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
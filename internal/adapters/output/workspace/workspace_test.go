@@ -0,0 +1,93 @@
+package workspace_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/workspace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Create(t *testing.T) {
+	t.Run("should create a directory per attempt under the root", func(t *testing.T) {
+		root := filepath.Join(t.TempDir(), "workspaces")
+		manager := workspace.NewManager(root, "worker-1")
+
+		first, err := manager.Create("job-123")
+		require.NoError(t, err)
+		second, err := manager.Create("job-123")
+		require.NoError(t, err)
+
+		assert.Equal(t, "job-123", first.JobID)
+		assert.Equal(t, filepath.Join(root, "worker-1"), filepath.Dir(first.Dir))
+		assert.NotEqual(t, first.Dir, second.Dir)
+		assert.DirExists(t, first.Dir)
+		assert.Equal(t, filepath.Join(first.Dir, "input.mp4"), first.Path("input.mp4"))
+	})
+
+	t.Run("should reject job IDs that escape the root", func(t *testing.T) {
+		manager := workspace.NewManager(t.TempDir(), "worker-1")
+
+		_, err := manager.Create("../job-123")
+		assert.Error(t, err)
+	})
+}
+
+func TestManager_Remove(t *testing.T) {
+	manager := workspace.NewManager(t.TempDir(), "worker-1")
+	ws, err := manager.Create("job-123")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ws.Path("input.mp4"), []byte("video"), 0o600))
+
+	err = manager.Remove(ws)
+
+	assert.NoError(t, err)
+	assert.NoDirExists(t, ws.Dir)
+}
+
+func TestManager_Sweep(t *testing.T) {
+	t.Run("should remove workspaces left by previous runs", func(t *testing.T) {
+		root := t.TempDir()
+		manager := workspace.NewManager(root, "worker-1")
+		for _, jobID := range []string{"job-1", "job-2"} {
+			ws, err := manager.Create(jobID)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(ws.Path("input.mp4"), []byte("video"), 0o600))
+		}
+
+		removed, err := manager.Sweep()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, removed)
+		entries, err := os.ReadDir(filepath.Join(root, "worker-1"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should leave the workspaces of other workers alone", func(t *testing.T) {
+		root := t.TempDir()
+		other, err := workspace.NewManager(root, "worker-2").Create("job-2")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(other.Path("input.mp4"), []byte("video"), 0o600))
+		manager := workspace.NewManager(root, "worker-1")
+		_, err = manager.Create("job-1")
+		require.NoError(t, err)
+
+		removed, err := manager.Sweep()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, removed)
+		assert.FileExists(t, other.Path("input.mp4"))
+	})
+
+	t.Run("should do nothing when the root does not exist yet", func(t *testing.T) {
+		manager := workspace.NewManager(filepath.Join(t.TempDir(), "missing"), "worker-1")
+
+		removed, err := manager.Sweep()
+
+		assert.NoError(t, err)
+		assert.Zero(t, removed)
+	})
+}
@@ -0,0 +1,47 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/config"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Client holds the NATS connection and the JetStream handles the queue
+// adapter uses.
+type Client struct {
+	Conn      *nats.Conn
+	JetStream jetstream.JetStream
+	Consumer  jetstream.Consumer
+}
+
+// NewNATSClient connects to NATS and creates, or updates, the durable pull
+// consumer the worker reads jobs from. A delivered message goes back to the
+// stream once the visibility timeout passes without an ack or a heartbeat.
+func NewNATSClient(ctx context.Context) (*Client, error) {
+	conn, err := nats.Connect(config.Vars.NATSURL, nats.Name("hackthon-soat-process-worker"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, config.Vars.NATSStream, jetstream.ConsumerConfig{
+		Durable:       config.Vars.NATSConsumer,
+		FilterSubject: config.Vars.NATSWorkSubject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       config.Vars.SQSVisibilityTimeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create consumer '%s' on stream '%s': %w", config.Vars.NATSConsumer, config.Vars.NATSStream, err)
+	}
+
+	return &Client{Conn: conn, JetStream: js, Consumer: consumer}, nil
+}
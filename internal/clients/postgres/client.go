@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 func NewPostgresClient() (*gorm.DB, error) {
@@ -14,7 +16,15 @@ func NewPostgresClient() (*gorm.DB, error) {
 		config.Vars.DBHost, config.Vars.DBPort, config.Vars.DBUser, config.Vars.DBPassword, config.Vars.DBName,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// Slow queries and errors go to the application log as warnings. A
+		// missing job is handled by the caller, so it isn't logged here.
+		Logger: gormlogger.New(slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn), gormlogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormlogger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
 	}
@@ -0,0 +1,26 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/config"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// NewRabbitMQClient connects to the broker and opens the channel the queue
+// adapter consumes and publishes on. Closing the connection closes the
+// channel too.
+func NewRabbitMQClient() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(config.Vars.RabbitMQURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+
+	return conn, channel, nil
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrObjectNotFound is returned by storage adapters when the requested object
+// does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrCorruptDownload is returned by storage adapters when a downloaded file
+// does not match the checksum or ETag of the stored object.
+var ErrCorruptDownload = errors.New("downloaded file is corrupt")
+
+// maxStderrBytes bounds how much of a tool's output is kept with an error.
+// The end is kept, since that is where ffmpeg explains why it gave up.
+const maxStderrBytes = 2048
+
+// StageError is returned by processors when a step of processing failed,
+// with the end of what the underlying tool wrote to stderr.
+type StageError struct {
+	Stage  JobStage
+	Stderr string
+	Err    error
+}
+
+func NewStageError(stage JobStage, stderr string, err error) error {
+	stderr = strings.TrimSpace(stderr)
+	if len(stderr) > maxStderrBytes {
+		stderr = "..." + strings.ToValidUTF8(stderr[len(stderr)-maxStderrBytes:], "")
+	}
+	return &StageError{Stage: stage, Stderr: stderr, Err: err}
+}
+
+func (e *StageError) Error() string { return e.Err.Error() }
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+// RetryableError marks a failure that may go away if the job is attempted
+// again, such as a storage timeout or a database blip.
+type RetryableError struct {
+	Err error
+}
+
+func NewRetryableError(err error) error {
+	return &RetryableError{Err: err}
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// PermanentError marks a failure that will happen again on every attempt,
+// such as an invalid video.
+type PermanentError struct {
+	Err error
+}
+
+func NewPermanentError(err error) error {
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// IsRetryable reports whether err should be retried. The outermost
+// classification in the chain wins, so a retryable error that was later
+// wrapped as permanent is not retried. Unclassified errors are permanent.
+func IsRetryable(err error) bool {
+	for err != nil {
+		switch err.(type) {
+		case *PermanentError:
+			return false
+		case *RetryableError:
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
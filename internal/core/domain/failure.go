@@ -0,0 +1,28 @@
+package domain
+
+// FailureReason says why a job attempt failed. Reasons are metric labels and
+// pick the message users are notified with, so the set must stay small and
+// fixed.
+type FailureReason string
+
+const (
+	FailureDatabase       FailureReason = "database"
+	FailureInvalidOptions FailureReason = "invalid_options"
+	FailureWorkspace      FailureReason = "workspace"
+	FailureVideoNotFound  FailureReason = "video_not_found"
+	FailureDownload       FailureReason = "download"
+	FailureProbe          FailureReason = "probe"
+	FailureInvalidVideo   FailureReason = "invalid_video"
+	FailureLimitExceeded  FailureReason = "limit_exceeded"
+	FailureProcessing     FailureReason = "processing"
+	FailureUpload         FailureReason = "upload"
+	FailureStatusConflict FailureReason = "status_conflict"
+)
+
+// JobFailure is what is recorded about a job that failed for good.
+type JobFailure struct {
+	Code    FailureReason
+	Stage   JobStage
+	Message string
+	Stderr  string
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "errors"
+
+type ImageFormat string
+
+const (
+	ImageFormatPNG  ImageFormat = "png"
+	ImageFormatJPG  ImageFormat = "jpg"
+	ImageFormatWEBP ImageFormat = "webp"
+)
+
+// ErrInvalidFrameOptions is returned when a job asks for frame extraction
+// options the worker cannot honour.
+var ErrInvalidFrameOptions = errors.New("invalid frame options")
+
+// FrameOptions controls how frames are extracted from a video. Zero values
+// mean "use the default": one PNG frame per second of the whole video at its
+// original size.
+type FrameOptions struct {
+	// FPS is how many frames are extracted per second of video.
+	FPS float64 `json:"fps,omitempty"`
+	// IntervalSeconds extracts one frame every given number of seconds. It
+	// cannot be combined with FPS.
+	IntervalSeconds float64 `json:"interval_seconds,omitempty"`
+	// Format is the image format of the extracted frames.
+	Format ImageFormat `json:"format,omitempty"`
+	// Quality goes from 1 (smallest) to 100 (best). It is ignored for PNG.
+	Quality int `json:"quality,omitempty"`
+	// MaxWidth and MaxHeight scale frames down, keeping the aspect ratio.
+	// Frames smaller than the limits are never scaled up.
+	MaxWidth  int `json:"max_width,omitempty"`
+	MaxHeight int `json:"max_height,omitempty"`
+	// StartSeconds and EndSeconds limit extraction to a part of the video.
+	StartSeconds float64 `json:"start_seconds,omitempty"`
+	EndSeconds   float64 `json:"end_seconds,omitempty"`
+}
+
+// Rate is the number of frames extracted per second of video.
+func (o FrameOptions) Rate() float64 {
+	if o.IntervalSeconds > 0 {
+		return 1 / o.IntervalSeconds
+	}
+	return o.FPS
+}
@@ -1,9 +1,131 @@
 package domain
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JobErrorEvent is published to the error queue when a job fails for good.
+// Code is one of the FailureReason values and Stderr the end of the tool's
+// output, when the failure came from ffmpeg or ffprobe.
 type JobErrorEvent struct {
-	JobID string `json:"job_id"`
+	JobID      string        `json:"job_id"`
+	Code       FailureReason `json:"code"`
+	Stage      JobStage      `json:"stage"`
+	Message    string        `json:"message"`
+	Stderr     string        `json:"stderr,omitempty"`
+	Attempt    int           `json:"attempt"`
+	WorkerID   string        `json:"worker_id"`
+	OccurredAt time.Time     `json:"occurred_at"`
 }
 
+// JobErrorEventType is the outbox event type of a JobErrorEvent.
+const JobErrorEventType = "job.error"
+
 type JobMessageEvent struct {
-	JobID     string `json:"job_id"`
+	JobID   string        `json:"job_id"`
+	Options *FrameOptions `json:"options,omitempty"`
+}
+
+// JobEventType names a step of a job's lifecycle. It is sent along with every
+// lifecycle event so consumers can filter on it.
+type JobEventType string
+
+const (
+	JobEventStarted   JobEventType = "job.started"
+	JobEventProgress  JobEventType = "job.progress"
+	JobEventCompleted JobEventType = "job.completed"
+	JobEventFailed    JobEventType = "job.failed"
+)
+
+// JobStage is a step of processing a job. Prepare covers everything before
+// the download, such as validating options and creating the workspace.
+type JobStage string
+
+const (
+	StagePrepare  JobStage = "prepare"
+	StageDownload JobStage = "download"
+	StageProbe    JobStage = "probe"
+	StageExtract  JobStage = "extract"
+	StageZip      JobStage = "zip"
+	StageUpload   JobStage = "upload"
+)
+
+// JobEvent is a lifecycle event published for other services to follow jobs
+// without polling the database.
+type JobEvent interface {
+	Header() JobEventHeader
+}
+
+// JobEventHeader holds the fields shared by every lifecycle event.
+type JobEventHeader struct {
+	Type       JobEventType `json:"type"`
+	JobID      string       `json:"job_id"`
+	UserID     string       `json:"user_id"`
+	WorkerID   string       `json:"worker_id"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
+
+func (h JobEventHeader) Header() JobEventHeader {
+	return h
+}
+
+// JobStartedEvent is published once a worker claimed a job.
+type JobStartedEvent struct {
+	JobEventHeader
+	Attempt int `json:"attempt"`
+}
+
+// JobProgressEvent is published when a job enters a new stage.
+type JobProgressEvent struct {
+	JobEventHeader
+	Stage JobStage `json:"stage"`
+}
+
+// JobCompletedEvent is published once a job's archive is uploaded and the job
+// is marked as completed. Extract and zip run at the same time as the upload,
+// so their durations overlap.
+type JobCompletedEvent struct {
+	JobEventHeader
+	OutputPath           string     `json:"output_path"`
+	DownloadURL          string     `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
+	FrameCount           int        `json:"frame_count"`
+	ArchiveSizeBytes     int64      `json:"archive_size_bytes"`
+	DurationMs           int64      `json:"duration_ms"`
+	ExtractDurationMs    int64      `json:"extract_duration_ms"`
+	ZipDurationMs        int64      `json:"zip_duration_ms"`
+}
+
+// JobFailedEvent is published once a job failed for good.
+type JobFailedEvent struct {
+	JobEventHeader
+	Reason  FailureReason `json:"reason"`
+	Stage   JobStage      `json:"stage"`
+	Attempt int           `json:"attempt"`
+}
+
+// DecodeJobEvent reads back a lifecycle event serialized as JSON.
+func DecodeJobEvent(eventType JobEventType, payload []byte) (JobEvent, error) {
+	switch eventType {
+	case JobEventStarted:
+		return decodeJobEvent[JobStartedEvent](eventType, payload)
+	case JobEventProgress:
+		return decodeJobEvent[JobProgressEvent](eventType, payload)
+	case JobEventCompleted:
+		return decodeJobEvent[JobCompletedEvent](eventType, payload)
+	case JobEventFailed:
+		return decodeJobEvent[JobFailedEvent](eventType, payload)
+	default:
+		return nil, fmt.Errorf("unknown job event type '%s'", eventType)
+	}
+}
+
+func decodeJobEvent[T JobEvent](eventType JobEventType, payload []byte) (JobEvent, error) {
+	var event T
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode '%s' event: %w", eventType, err)
+	}
+	return event, nil
 }
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a job message received from the work queue, whatever the broker
+// behind it. Settling it goes back to that broker through its Acker.
+type Message struct {
+	ID   string
+	Body []byte
+	// Attributes carry the message metadata the broker delivered with it,
+	// such as the upstream trace context.
+	Attributes map[string]string
+	// Attempt is how many times the broker has delivered the message,
+	// starting at 1.
+	Attempt int
+	Acker   MessageAcker
+}
+
+// MessageAcker settles a received message on the broker that delivered it.
+//
+//go:generate mockgen -destination=../ports/mocks/mock_messageacker.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain MessageAcker
+type MessageAcker interface {
+	// Ack removes the message from the queue for good.
+	Ack(ctx context.Context) error
+	// Nack gives the message back to the queue to be delivered again after
+	// delay.
+	Nack(ctx context.Context, delay time.Duration) error
+	// Extend keeps the message from being delivered to another worker for
+	// timeout more.
+	Extend(ctx context.Context, timeout time.Duration) error
+}
+
+func (m Message) Ack(ctx context.Context) error {
+	return m.Acker.Ack(ctx)
+}
+
+func (m Message) Nack(ctx context.Context, delay time.Duration) error {
+	return m.Acker.Nack(ctx, delay)
+}
+
+func (m Message) Extend(ctx context.Context, timeout time.Duration) error {
+	return m.Acker.Extend(ctx, timeout)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutboxDestination says where the relay publishes an outbox message.
+type OutboxDestination string
+
+const (
+	OutboxErrorQueue OutboxDestination = "error_queue"
+	OutboxEvents     OutboxDestination = "events"
+)
+
+// OutboxMessage is an event written in the same transaction as the status
+// change it announces, and published later by the outbox relay. A message is
+// due once AvailableAt has passed and SentAt is still empty.
+type OutboxMessage struct {
+	ID          string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid();" json:"id"`
+	JobID       string            `gorm:"type:uuid;not null;" json:"job_id"`
+	Destination OutboxDestination `gorm:"type:varchar(50);not null;" json:"destination"`
+	EventType   string            `gorm:"type:varchar(50);not null;" json:"event_type"`
+	Payload     string            `gorm:"type:jsonb;not null;" json:"payload"`
+	Headers     OutboxHeaders     `gorm:"type:jsonb;" json:"headers"`
+	Attempts    int               `gorm:"type:integer;not null;default:0;" json:"attempts"`
+	LastError   *string           `gorm:"type:text;" json:"last_error"`
+	AvailableAt time.Time         `gorm:"type:timestamptz;not null;default:now();" json:"available_at"`
+	CreatedAt   time.Time         `gorm:"type:timestamptz;default:now();" json:"created_at"`
+	SentAt      *time.Time        `gorm:"type:timestamptz;" json:"sent_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "tb_job_outbox"
+}
+
+// OutboxHeaders carry the trace context of the transaction that wrote a
+// message, so publishing it continues the job's trace.
+type OutboxHeaders map[string]string
+
+// Value stores the headers as a jsonb column.
+func (h OutboxHeaders) Value() (driver.Value, error) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the headers back from a jsonb column.
+func (h *OutboxHeaders) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return fmt.Errorf("unsupported outbox headers type %T", value)
+	}
+}
@@ -1,18 +1,56 @@
 package domain
 
 import (
-	"os"
+	"errors"
+	"slices"
 	"time"
 )
 
 type VideoStatus string
 
 const (
+	VideoStatusQueued     VideoStatus = "queued"
 	VideoStatusProcessing VideoStatus = "processing"
 	VideoStatusCompleted  VideoStatus = "completed"
 	VideoStatusFailed     VideoStatus = "failed"
 )
 
+var (
+	// ErrJobNotFound is returned by job repositories when the requested job
+	// does not exist.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrInvalidTransition is returned when a job is asked to move to a
+	// status that is not reachable from its current one.
+	ErrInvalidTransition = errors.New("invalid job status transition")
+	// ErrStatusConflict is returned when an update matched no row because the
+	// stored job no longer is in the state it was based on, meaning another
+	// worker changed it.
+	ErrStatusConflict = errors.New("job status changed concurrently")
+	// ErrJobAlreadyClaimed is returned when another worker holds a valid
+	// lease on the job.
+	ErrJobAlreadyClaimed = errors.New("job already claimed by another worker")
+	// ErrLeaseLost is returned when a lease can no longer be extended because
+	// it expired and was released or taken over.
+	ErrLeaseLost = errors.New("job lease lost")
+)
+
+// statusTransitions lists the statuses each status may move to. Completed is
+// terminal. Processing may be entered again when a message is redelivered
+// after a retryable failure, and goes back to queued when its lease expires or
+// is released for a retry.
+// A failed job is never claimed again as is: retrying it takes an explicit
+// re-queue that moves it back to queued, so a duplicate or stale message for
+// it is discarded.
+var statusTransitions = map[VideoStatus][]VideoStatus{
+	VideoStatusQueued:     {VideoStatusProcessing},
+	VideoStatusProcessing: {VideoStatusProcessing, VideoStatusCompleted, VideoStatusFailed, VideoStatusQueued},
+	VideoStatusFailed:     {VideoStatusQueued},
+}
+
+func (s VideoStatus) CanTransitionTo(next VideoStatus) bool {
+	return slices.Contains(statusTransitions[s], next)
+}
+
 type VideoJobDTO struct {
 	ID         string      `gorm:"primaryKey;type:uuid;" json:"job_id"`
 	Status     VideoStatus `gorm:"type:varchar(20);not null;" json:"status"`
@@ -24,26 +62,68 @@ type VideoJobDTO struct {
 }
 
 type VideoJob struct {
-	ID         string      `gorm:"primaryKey;type:uuid;" json:"job_id"`
-	Status     VideoStatus `gorm:"type:varchar(20);not null;" json:"status"`
-	CreatedAt  string      `gorm:"type:timestamp;not null;" json:"created_at"`
-	OutputPath *string     `gorm:"type:varchar(255);" json:"output_path"`
-	UserID     string      `gorm:"not null;" json:"user_id"`
-	VideoPath  string      `gorm:"type:varchar(255);not null;" json:"video_path"`
+	ID                   string         `gorm:"primaryKey;type:uuid;" json:"job_id"`
+	Status               VideoStatus    `gorm:"type:varchar(20);not null;" json:"status"`
+	CreatedAt            string         `gorm:"type:timestamp;not null;" json:"created_at"`
+	OutputPath           *string        `gorm:"type:varchar(255);" json:"output_path"`
+	UserID               string         `gorm:"not null;" json:"user_id"`
+	VideoPath            string         `gorm:"type:varchar(255);not null;" json:"video_path"`
+	LeaseOwner           *string        `gorm:"type:varchar(255);" json:"lease_owner"`
+	LeaseExpiresAt       *time.Time     `gorm:"type:timestamptz;" json:"lease_expires_at"`
+	FrameCount           *int           `gorm:"type:integer;" json:"frame_count"`
+	ArchiveSize          *int64         `gorm:"column:archive_size_bytes;type:bigint;" json:"archive_size_bytes"`
+	DownloadURL          *string        `gorm:"type:text;" json:"download_url"`
+	DownloadURLExpiresAt *time.Time     `gorm:"type:timestamptz;" json:"download_url_expires_at"`
+	FailureCode          *FailureReason `gorm:"type:varchar(50);" json:"failure_code"`
+	FailureStage         *JobStage      `gorm:"type:varchar(50);" json:"failure_stage"`
+	FailureMessage       *string        `gorm:"type:text;" json:"failure_message"`
+	VideoMetadata        *VideoMetadata `gorm:"type:jsonb;" json:"video_metadata"`
 }
 
 type DownloadedFile struct {
-	Path string
-	File *os.File
+	Path      string
+	SizeBytes int64
+}
+
+// ProcessedArchive describes the zip produced from a video's frames.
+// ExtractDuration is how long ffmpeg ran and ZipDuration how much of that
+// time was spent compressing and writing frames out.
+type ProcessedArchive struct {
+	FrameCount      int
+	SizeBytes       int64
+	ExtractDuration time.Duration
+	ZipDuration     time.Duration
+}
+
+// JobStats are the results stored on a job once it completes. Download is
+// nil when no link could be generated for the archive.
+type JobStats struct {
+	FrameCount  int
+	ArchiveSize int64
+	Download    *DownloadLink
+}
+
+// DownloadLink is a URL anyone can use to fetch an archive until it expires.
+type DownloadLink struct {
+	URL       string
+	ExpiresAt time.Time
 }
 
+// JobStatusHistory is one entry of the audit trail written on every status
+// transition of a job.
 type JobStatusHistory struct {
-	ID        string      `gorm:"primaryKey;type:uuid;" json:"id"`
+	ID        string      `gorm:"primaryKey;type:uuid;default:gen_random_uuid();" json:"id"`
 	JobID     string      `gorm:"type:uuid;not null;" json:"job_id"`
 	Status    VideoStatus `gorm:"type:varchar(20);not null;" json:"status"`
+	Reason    *string     `gorm:"type:text;" json:"reason"`
+	WorkerID  string      `gorm:"type:varchar(255);not null;" json:"worker_id"`
 	CreatedAt time.Time   `json:"created_at" gorm:"type:timestamptz;default:now()"`
 }
 
 func (VideoJob) TableName() string {
 	return "tb_video_jobs"
 }
+
+func (JobStatusHistory) TableName() string {
+	return "tb_video_job_status_history"
+}
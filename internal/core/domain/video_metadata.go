@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidVideo is returned when the input file is not a video that can
+	// be processed, such as an image or a corrupt upload.
+	ErrInvalidVideo = errors.New("input is not a valid video")
+	// ErrVideoLimitExceeded is returned when a video is longer, larger or has
+	// a higher resolution than the worker accepts.
+	ErrVideoLimitExceeded = errors.New("video exceeds processing limits")
+)
+
+// VideoMetadata describes an input video as reported by ffprobe.
+type VideoMetadata struct {
+	FormatName      string  `json:"format_name"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	SizeBytes       int64   `json:"size_bytes"`
+	BitRate         int64   `json:"bit_rate,omitempty"`
+	VideoCodec      string  `json:"video_codec"`
+	AudioCodec      string  `json:"audio_codec,omitempty"`
+	Width           int     `json:"width"`
+	Height          int     `json:"height"`
+	FrameRate       float64 `json:"frame_rate"`
+}
+
+// Value stores the metadata as a jsonb column.
+func (m VideoMetadata) Value() (driver.Value, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the metadata back from a jsonb column.
+func (m *VideoMetadata) Scan(value any) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("unsupported video metadata type %T", value)
+	}
+}
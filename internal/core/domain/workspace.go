@@ -0,0 +1,15 @@
+package domain
+
+import "path/filepath"
+
+// Workspace is the scratch directory holding every intermediate file of one
+// job attempt: the downloaded video, extracted frames and the archive.
+type Workspace struct {
+	JobID string
+	Dir   string
+}
+
+// Path returns the location of name inside the workspace.
+func (w *Workspace) Path(name string) string {
+	return filepath.Join(w.Dir, name)
+}
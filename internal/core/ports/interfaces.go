@@ -2,17 +2,36 @@ package ports
 
 import (
 	"context"
+	"io"
+	"time"
 
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 //go:generate mockgen -destination=mocks/mock_s3client.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports S3Client
 type S3Client interface {
 	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
 	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// S3Presigner signs S3 requests that can be sent later without credentials.
+//
+//go:generate mockgen -destination=mocks/mock_s3presigner.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports S3Presigner
+type S3Presigner interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
 }
 
 //go:generate mockgen -destination=mocks/mock_sqsclient.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports SQSClient
@@ -20,33 +39,127 @@ type SQSClient interface {
 	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
 	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
 	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
+//go:generate mockgen -destination=mocks/mock_snsclient.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports SNSClient
+type SNSClient interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+	GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error)
+}
+
+// AMQPChannel is the part of a RabbitMQ channel the queue adapter uses.
+//
+//go:generate mockgen -destination=mocks/mock_amqpchannel.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports AMQPChannel
+type AMQPChannel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	IsClosed() bool
+}
+
+// JetStreamConsumer is the part of a NATS JetStream pull consumer the queue
+// adapter uses.
+//
+//go:generate mockgen -destination=mocks/mock_jetstreamconsumer.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports JetStreamConsumer
+type JetStreamConsumer interface {
+	Fetch(batch int, opts ...jetstream.FetchOpt) (jetstream.MessageBatch, error)
+	FetchNoWait(batch int) (jetstream.MessageBatch, error)
+	Info(ctx context.Context) (*jetstream.ConsumerInfo, error)
+}
+
+//go:generate mockgen -destination=mocks/mock_jetstreampublisher.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports JetStreamPublisher
+type JetStreamPublisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
 }
 
 //go:generate mockgen -destination=mocks/mock_videojobrepository.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports VideoJobRepository
 type VideoJobRepository interface {
 	GetJobByID(ctx context.Context, jobID string) (*domain.VideoJobDTO, error)
-	UpdateJobStatus(ctx context.Context, videoJob *domain.VideoJob) error
+	SaveVideoMetadata(ctx context.Context, jobID, workerID string, metadata domain.VideoMetadata) error
+	MarkCompleted(ctx context.Context, jobID, workerID, outputPath string, stats domain.JobStats, outbox []domain.OutboxMessage) (int64, error)
+	MarkFailed(ctx context.Context, jobID, workerID string, failure domain.JobFailure, outbox []domain.OutboxMessage) (int64, error)
+	ListJobHistory(ctx context.Context, jobID string) ([]domain.JobStatusHistory, error)
+	ClaimJob(ctx context.Context, jobID, workerID string, leaseDuration time.Duration) error
+	ExtendLease(ctx context.Context, jobID, workerID string, leaseDuration time.Duration) error
+	ReleaseJob(ctx context.Context, jobID, workerID, reason string) error
+	ReleaseExpiredLeases(ctx context.Context, workerID string) (int64, error)
+}
+
+// OutboxRepository hands out the outbox messages that are due for the relay
+// to publish.
+//
+//go:generate mockgen -destination=mocks/mock_outboxrepository.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports OutboxRepository
+type OutboxRepository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, cause string, retryAt time.Time) error
 }
 
 //go:generate mockgen -destination=mocks/mock_s3adapter.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports S3Adapter
 type S3Adapter interface {
-	DownloadFile(ctx context.Context, objectKey string) (*domain.DownloadedFile, error)
+	DownloadFile(ctx context.Context, objectKey string, workspace *domain.Workspace) (*domain.DownloadedFile, error)
 	UploadFile(ctx context.Context, localFilePath, objectKey string) error
+	UploadStream(ctx context.Context, objectKey string, body io.Reader) error
+	PresignDownload(ctx context.Context, objectKey string) (*domain.DownloadLink, error)
 }
 
-//go:generate mockgen -destination=mocks/mock_sqsadapter.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports SQSAdapter
-type SQSAdapter interface {
+// QueueAdapter receives job messages from the work queue and publishes
+// failures to the error queue, whichever broker holds them. Receive waits up
+// to wait for a first message and returns at most maxMessages.
+//
+//go:generate mockgen -destination=mocks/mock_queueadapter.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports QueueAdapter
+type QueueAdapter interface {
 	Publish(ctx context.Context, event domain.JobErrorEvent) error
-	Receive(ctx context.Context, maxMessages int32, waitTimeSeconds int32) ([]types.Message, error)
-	Delete(ctx context.Context, receiptHandle string) error
+	Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]domain.Message, error)
+	Ping(ctx context.Context) error
 }
 
 //go:generate mockgen -destination=mocks/mock_processoradapter.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports ProcessorAdapter
 type ProcessorAdapter interface {
-	Process(ctx context.Context, localVideoPath string) (string, string, error)
+	ProbeVideo(ctx context.Context, localVideoPath string) (*domain.VideoMetadata, error)
+	Process(ctx context.Context, localVideoPath string, opts domain.FrameOptions, output io.Writer) (*domain.ProcessedArchive, error)
+}
+
+//go:generate mockgen -destination=mocks/mock_workspacemanager.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports WorkspaceManager
+type WorkspaceManager interface {
+	Create(jobID string) (*domain.Workspace, error)
+	Remove(workspace *domain.Workspace) error
+}
+
+// EventPublisher announces the lifecycle of jobs to other services.
+//
+//go:generate mockgen -destination=mocks/mock_eventpublisher.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports EventPublisher
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.JobEvent) error
+}
+
+// Notifier tells users how their jobs ended.
+//
+//go:generate mockgen -destination=mocks/mock_notifier.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports Notifier
+type Notifier interface {
+	JobCompleted(ctx context.Context, job *domain.VideoJobDTO, download *domain.DownloadLink) error
+	JobFailed(ctx context.Context, job *domain.VideoJobDTO, reason domain.FailureReason) error
+}
+
+// MetricsRecorder collects the worker's operational metrics.
+//
+//go:generate mockgen -destination=mocks/mock_metricsrecorder.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports MetricsRecorder
+type MetricsRecorder interface {
+	JobReceived()
+	JobStarted()
+	JobFinished()
+	JobSucceeded(duration time.Duration)
+	JobFailed(reason domain.FailureReason, retrying bool)
+	ObserveStage(stage string, duration time.Duration)
+	AddBytesDownloaded(n int64)
+	AddBytesUploaded(n int64)
+	ReceiveFailed()
 }
 
 //go:generate mockgen -destination=mocks/mock_jobservice.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports JobService
 type JobService interface {
-	ProcessJob(ctx context.Context, jobID string) error
+	ProcessJob(ctx context.Context, event domain.JobMessageEvent, attempt int) error
 }
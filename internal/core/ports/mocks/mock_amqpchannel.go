@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: AMQPChannel)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_amqpchannel.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports AMQPChannel
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAMQPChannel is a mock of AMQPChannel interface.
+type MockAMQPChannel struct {
+	ctrl     *gomock.Controller
+	recorder *MockAMQPChannelMockRecorder
+	isgomock struct{}
+}
+
+// MockAMQPChannelMockRecorder is the mock recorder for MockAMQPChannel.
+type MockAMQPChannelMockRecorder struct {
+	mock *MockAMQPChannel
+}
+
+// NewMockAMQPChannel creates a new mock instance.
+func NewMockAMQPChannel(ctrl *gomock.Controller) *MockAMQPChannel {
+	mock := &MockAMQPChannel{ctrl: ctrl}
+	mock.recorder = &MockAMQPChannelMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAMQPChannel) EXPECT() *MockAMQPChannelMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockAMQPChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp091.Table) (<-chan amqp091.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+	ret0, _ := ret[0].(<-chan amqp091.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockAMQPChannelMockRecorder) Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockAMQPChannel)(nil).Consume), queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+}
+
+// IsClosed mocks base method.
+func (m *MockAMQPChannel) IsClosed() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsClosed")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsClosed indicates an expected call of IsClosed.
+func (mr *MockAMQPChannelMockRecorder) IsClosed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsClosed", reflect.TypeOf((*MockAMQPChannel)(nil).IsClosed))
+}
+
+// PublishWithContext mocks base method.
+func (m *MockAMQPChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishWithContext", ctx, exchange, key, mandatory, immediate, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishWithContext indicates an expected call of PublishWithContext.
+func (mr *MockAMQPChannelMockRecorder) PublishWithContext(ctx, exchange, key, mandatory, immediate, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishWithContext", reflect.TypeOf((*MockAMQPChannel)(nil).PublishWithContext), ctx, exchange, key, mandatory, immediate, msg)
+}
+
+// Qos mocks base method.
+func (m *MockAMQPChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Qos", prefetchCount, prefetchSize, global)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Qos indicates an expected call of Qos.
+func (mr *MockAMQPChannelMockRecorder) Qos(prefetchCount, prefetchSize, global any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Qos", reflect.TypeOf((*MockAMQPChannel)(nil).Qos), prefetchCount, prefetchSize, global)
+}
+
+// QueueDeclare mocks base method.
+func (m *MockAMQPChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp091.Table) (amqp091.Queue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueueDeclare", name, durable, autoDelete, exclusive, noWait, args)
+	ret0, _ := ret[0].(amqp091.Queue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueueDeclare indicates an expected call of QueueDeclare.
+func (mr *MockAMQPChannelMockRecorder) QueueDeclare(name, durable, autoDelete, exclusive, noWait, args any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueDeclare", reflect.TypeOf((*MockAMQPChannel)(nil).QueueDeclare), name, durable, autoDelete, exclusive, noWait, args)
+}
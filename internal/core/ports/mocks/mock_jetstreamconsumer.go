@@ -0,0 +1,92 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: JetStreamConsumer)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_jetstreamconsumer.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports JetStreamConsumer
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	jetstream "github.com/nats-io/nats.go/jetstream"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJetStreamConsumer is a mock of JetStreamConsumer interface.
+type MockJetStreamConsumer struct {
+	ctrl     *gomock.Controller
+	recorder *MockJetStreamConsumerMockRecorder
+	isgomock struct{}
+}
+
+// MockJetStreamConsumerMockRecorder is the mock recorder for MockJetStreamConsumer.
+type MockJetStreamConsumerMockRecorder struct {
+	mock *MockJetStreamConsumer
+}
+
+// NewMockJetStreamConsumer creates a new mock instance.
+func NewMockJetStreamConsumer(ctrl *gomock.Controller) *MockJetStreamConsumer {
+	mock := &MockJetStreamConsumer{ctrl: ctrl}
+	mock.recorder = &MockJetStreamConsumerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJetStreamConsumer) EXPECT() *MockJetStreamConsumerMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method.
+func (m *MockJetStreamConsumer) Fetch(batch int, opts ...jetstream.FetchOpt) (jetstream.MessageBatch, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{batch}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Fetch", varargs...)
+	ret0, _ := ret[0].(jetstream.MessageBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fetch indicates an expected call of Fetch.
+func (mr *MockJetStreamConsumerMockRecorder) Fetch(batch any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{batch}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockJetStreamConsumer)(nil).Fetch), varargs...)
+}
+
+// FetchNoWait mocks base method.
+func (m *MockJetStreamConsumer) FetchNoWait(batch int) (jetstream.MessageBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchNoWait", batch)
+	ret0, _ := ret[0].(jetstream.MessageBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchNoWait indicates an expected call of FetchNoWait.
+func (mr *MockJetStreamConsumerMockRecorder) FetchNoWait(batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchNoWait", reflect.TypeOf((*MockJetStreamConsumer)(nil).FetchNoWait), batch)
+}
+
+// Info mocks base method.
+func (m *MockJetStreamConsumer) Info(ctx context.Context) (*jetstream.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Info", ctx)
+	ret0, _ := ret[0].(*jetstream.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Info indicates an expected call of Info.
+func (mr *MockJetStreamConsumerMockRecorder) Info(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockJetStreamConsumer)(nil).Info), ctx)
+}
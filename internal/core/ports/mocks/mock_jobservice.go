@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,15 +42,15 @@ func (m *MockJobService) EXPECT() *MockJobServiceMockRecorder {
 }
 
 // ProcessJob mocks base method.
-func (m *MockJobService) ProcessJob(ctx context.Context, jobID string) error {
+func (m *MockJobService) ProcessJob(ctx context.Context, event domain.JobMessageEvent, attempt int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ProcessJob", ctx, jobID)
+	ret := m.ctrl.Call(m, "ProcessJob", ctx, event, attempt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ProcessJob indicates an expected call of ProcessJob.
-func (mr *MockJobServiceMockRecorder) ProcessJob(ctx, jobID any) *gomock.Call {
+func (mr *MockJobServiceMockRecorder) ProcessJob(ctx, event, attempt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessJob", reflect.TypeOf((*MockJobService)(nil).ProcessJob), ctx, jobID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessJob", reflect.TypeOf((*MockJobService)(nil).ProcessJob), ctx, event, attempt)
 }
@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain (interfaces: MessageAcker)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../ports/mocks/mock_messageacker.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain MessageAcker
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageAcker is a mock of MessageAcker interface.
+type MockMessageAcker struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageAckerMockRecorder
+	isgomock struct{}
+}
+
+// MockMessageAckerMockRecorder is the mock recorder for MockMessageAcker.
+type MockMessageAckerMockRecorder struct {
+	mock *MockMessageAcker
+}
+
+// NewMockMessageAcker creates a new mock instance.
+func NewMockMessageAcker(ctrl *gomock.Controller) *MockMessageAcker {
+	mock := &MockMessageAcker{ctrl: ctrl}
+	mock.recorder = &MockMessageAckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageAcker) EXPECT() *MockMessageAckerMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockMessageAcker) Ack(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockMessageAckerMockRecorder) Ack(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockMessageAcker)(nil).Ack), ctx)
+}
+
+// Extend mocks base method.
+func (m *MockMessageAcker) Extend(ctx context.Context, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extend", ctx, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Extend indicates an expected call of Extend.
+func (mr *MockMessageAckerMockRecorder) Extend(ctx, timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extend", reflect.TypeOf((*MockMessageAcker)(nil).Extend), ctx, timeout)
+}
+
+// Nack mocks base method.
+func (m *MockMessageAcker) Nack(ctx context.Context, delay time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nack", ctx, delay)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Nack indicates an expected call of Nack.
+func (mr *MockMessageAckerMockRecorder) Nack(ctx, delay any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nack", reflect.TypeOf((*MockMessageAcker)(nil).Nack), ctx, delay)
+}
@@ -0,0 +1,150 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: MetricsRecorder)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_metricsrecorder.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports MetricsRecorder
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMetricsRecorder is a mock of MetricsRecorder interface.
+type MockMetricsRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsRecorderMockRecorder
+	isgomock struct{}
+}
+
+// MockMetricsRecorderMockRecorder is the mock recorder for MockMetricsRecorder.
+type MockMetricsRecorderMockRecorder struct {
+	mock *MockMetricsRecorder
+}
+
+// NewMockMetricsRecorder creates a new mock instance.
+func NewMockMetricsRecorder(ctrl *gomock.Controller) *MockMetricsRecorder {
+	mock := &MockMetricsRecorder{ctrl: ctrl}
+	mock.recorder = &MockMetricsRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetricsRecorder) EXPECT() *MockMetricsRecorderMockRecorder {
+	return m.recorder
+}
+
+// AddBytesDownloaded mocks base method.
+func (m *MockMetricsRecorder) AddBytesDownloaded(n int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddBytesDownloaded", n)
+}
+
+// AddBytesDownloaded indicates an expected call of AddBytesDownloaded.
+func (mr *MockMetricsRecorderMockRecorder) AddBytesDownloaded(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBytesDownloaded", reflect.TypeOf((*MockMetricsRecorder)(nil).AddBytesDownloaded), n)
+}
+
+// AddBytesUploaded mocks base method.
+func (m *MockMetricsRecorder) AddBytesUploaded(n int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddBytesUploaded", n)
+}
+
+// AddBytesUploaded indicates an expected call of AddBytesUploaded.
+func (mr *MockMetricsRecorderMockRecorder) AddBytesUploaded(n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBytesUploaded", reflect.TypeOf((*MockMetricsRecorder)(nil).AddBytesUploaded), n)
+}
+
+// JobFailed mocks base method.
+func (m *MockMetricsRecorder) JobFailed(reason domain.FailureReason, retrying bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobFailed", reason, retrying)
+}
+
+// JobFailed indicates an expected call of JobFailed.
+func (mr *MockMetricsRecorderMockRecorder) JobFailed(reason, retrying any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobFailed", reflect.TypeOf((*MockMetricsRecorder)(nil).JobFailed), reason, retrying)
+}
+
+// JobFinished mocks base method.
+func (m *MockMetricsRecorder) JobFinished() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobFinished")
+}
+
+// JobFinished indicates an expected call of JobFinished.
+func (mr *MockMetricsRecorderMockRecorder) JobFinished() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobFinished", reflect.TypeOf((*MockMetricsRecorder)(nil).JobFinished))
+}
+
+// JobReceived mocks base method.
+func (m *MockMetricsRecorder) JobReceived() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobReceived")
+}
+
+// JobReceived indicates an expected call of JobReceived.
+func (mr *MockMetricsRecorderMockRecorder) JobReceived() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobReceived", reflect.TypeOf((*MockMetricsRecorder)(nil).JobReceived))
+}
+
+// JobStarted mocks base method.
+func (m *MockMetricsRecorder) JobStarted() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobStarted")
+}
+
+// JobStarted indicates an expected call of JobStarted.
+func (mr *MockMetricsRecorderMockRecorder) JobStarted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobStarted", reflect.TypeOf((*MockMetricsRecorder)(nil).JobStarted))
+}
+
+// JobSucceeded mocks base method.
+func (m *MockMetricsRecorder) JobSucceeded(duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JobSucceeded", duration)
+}
+
+// JobSucceeded indicates an expected call of JobSucceeded.
+func (mr *MockMetricsRecorderMockRecorder) JobSucceeded(duration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobSucceeded", reflect.TypeOf((*MockMetricsRecorder)(nil).JobSucceeded), duration)
+}
+
+// ObserveStage mocks base method.
+func (m *MockMetricsRecorder) ObserveStage(stage string, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveStage", stage, duration)
+}
+
+// ObserveStage indicates an expected call of ObserveStage.
+func (mr *MockMetricsRecorderMockRecorder) ObserveStage(stage, duration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveStage", reflect.TypeOf((*MockMetricsRecorder)(nil).ObserveStage), stage, duration)
+}
+
+// ReceiveFailed mocks base method.
+func (m *MockMetricsRecorder) ReceiveFailed() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceiveFailed")
+}
+
+// ReceiveFailed indicates an expected call of ReceiveFailed.
+func (mr *MockMetricsRecorderMockRecorder) ReceiveFailed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveFailed", reflect.TypeOf((*MockMetricsRecorder)(nil).ReceiveFailed))
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: Notifier)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_notifier.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports Notifier
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotifierMockRecorder
+	isgomock struct{}
+}
+
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
+}
+
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
+	return m.recorder
+}
+
+// JobCompleted mocks base method.
+func (m *MockNotifier) JobCompleted(ctx context.Context, job *domain.VideoJobDTO, download *domain.DownloadLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JobCompleted", ctx, job, download)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// JobCompleted indicates an expected call of JobCompleted.
+func (mr *MockNotifierMockRecorder) JobCompleted(ctx, job, download any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobCompleted", reflect.TypeOf((*MockNotifier)(nil).JobCompleted), ctx, job, download)
+}
+
+// JobFailed mocks base method.
+func (m *MockNotifier) JobFailed(ctx context.Context, job *domain.VideoJobDTO, reason domain.FailureReason) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JobFailed", ctx, job, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// JobFailed indicates an expected call of JobFailed.
+func (mr *MockNotifierMockRecorder) JobFailed(ctx, job, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobFailed", reflect.TypeOf((*MockNotifier)(nil).JobFailed), ctx, job, reason)
+}
@@ -11,8 +11,10 @@ package mocks
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 
+	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,18 +42,32 @@ func (m *MockProcessorAdapter) EXPECT() *MockProcessorAdapterMockRecorder {
 	return m.recorder
 }
 
+// ProbeVideo mocks base method.
+func (m *MockProcessorAdapter) ProbeVideo(ctx context.Context, localVideoPath string) (*domain.VideoMetadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProbeVideo", ctx, localVideoPath)
+	ret0, _ := ret[0].(*domain.VideoMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProbeVideo indicates an expected call of ProbeVideo.
+func (mr *MockProcessorAdapterMockRecorder) ProbeVideo(ctx, localVideoPath any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeVideo", reflect.TypeOf((*MockProcessorAdapter)(nil).ProbeVideo), ctx, localVideoPath)
+}
+
 // Process mocks base method.
-func (m *MockProcessorAdapter) Process(ctx context.Context, localVideoPath string) (string, string, error) {
+func (m *MockProcessorAdapter) Process(ctx context.Context, localVideoPath string, opts domain.FrameOptions, output io.Writer) (*domain.ProcessedArchive, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Process", ctx, localVideoPath)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(string)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "Process", ctx, localVideoPath, opts, output)
+	ret0, _ := ret[0].(*domain.ProcessedArchive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Process indicates an expected call of Process.
-func (mr *MockProcessorAdapterMockRecorder) Process(ctx, localVideoPath any) *gomock.Call {
+func (mr *MockProcessorAdapterMockRecorder) Process(ctx, localVideoPath, opts, output any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockProcessorAdapter)(nil).Process), ctx, localVideoPath)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Process", reflect.TypeOf((*MockProcessorAdapter)(nil).Process), ctx, localVideoPath, opts, output)
 }
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: QueueAdapter)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_queueadapter.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports QueueAdapter
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQueueAdapter is a mock of QueueAdapter interface.
+type MockQueueAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueueAdapterMockRecorder
+	isgomock struct{}
+}
+
+// MockQueueAdapterMockRecorder is the mock recorder for MockQueueAdapter.
+type MockQueueAdapterMockRecorder struct {
+	mock *MockQueueAdapter
+}
+
+// NewMockQueueAdapter creates a new mock instance.
+func NewMockQueueAdapter(ctrl *gomock.Controller) *MockQueueAdapter {
+	mock := &MockQueueAdapter{ctrl: ctrl}
+	mock.recorder = &MockQueueAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQueueAdapter) EXPECT() *MockQueueAdapterMockRecorder {
+	return m.recorder
+}
+
+// Ping mocks base method.
+func (m *MockQueueAdapter) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockQueueAdapterMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockQueueAdapter)(nil).Ping), ctx)
+}
+
+// Publish mocks base method.
+func (m *MockQueueAdapter) Publish(ctx context.Context, event domain.JobErrorEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockQueueAdapterMockRecorder) Publish(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockQueueAdapter)(nil).Publish), ctx, event)
+}
+
+// Receive mocks base method.
+func (m *MockQueueAdapter) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]domain.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Receive", ctx, maxMessages, wait)
+	ret0, _ := ret[0].([]domain.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive.
+func (mr *MockQueueAdapterMockRecorder) Receive(ctx, maxMessages, wait any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*MockQueueAdapter)(nil).Receive), ctx, maxMessages, wait)
+}
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 
 	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
@@ -42,18 +43,33 @@ func (m *MockS3Adapter) EXPECT() *MockS3AdapterMockRecorder {
 }
 
 // DownloadFile mocks base method.
-func (m *MockS3Adapter) DownloadFile(ctx context.Context, objectKey string) (*domain.DownloadedFile, error) {
+func (m *MockS3Adapter) DownloadFile(ctx context.Context, objectKey string, workspace *domain.Workspace) (*domain.DownloadedFile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DownloadFile", ctx, objectKey)
+	ret := m.ctrl.Call(m, "DownloadFile", ctx, objectKey, workspace)
 	ret0, _ := ret[0].(*domain.DownloadedFile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DownloadFile indicates an expected call of DownloadFile.
-func (mr *MockS3AdapterMockRecorder) DownloadFile(ctx, objectKey any) *gomock.Call {
+func (mr *MockS3AdapterMockRecorder) DownloadFile(ctx, objectKey, workspace any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockS3Adapter)(nil).DownloadFile), ctx, objectKey)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockS3Adapter)(nil).DownloadFile), ctx, objectKey, workspace)
+}
+
+// PresignDownload mocks base method.
+func (m *MockS3Adapter) PresignDownload(ctx context.Context, objectKey string) (*domain.DownloadLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PresignDownload", ctx, objectKey)
+	ret0, _ := ret[0].(*domain.DownloadLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PresignDownload indicates an expected call of PresignDownload.
+func (mr *MockS3AdapterMockRecorder) PresignDownload(ctx, objectKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PresignDownload", reflect.TypeOf((*MockS3Adapter)(nil).PresignDownload), ctx, objectKey)
 }
 
 // UploadFile mocks base method.
@@ -69,3 +85,17 @@ func (mr *MockS3AdapterMockRecorder) UploadFile(ctx, localFilePath, objectKey an
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockS3Adapter)(nil).UploadFile), ctx, localFilePath, objectKey)
 }
+
+// UploadStream mocks base method.
+func (m *MockS3Adapter) UploadStream(ctx context.Context, objectKey string, body io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadStream", ctx, objectKey, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadStream indicates an expected call of UploadStream.
+func (mr *MockS3AdapterMockRecorder) UploadStream(ctx, objectKey, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStream", reflect.TypeOf((*MockS3Adapter)(nil).UploadStream), ctx, objectKey, body)
+}
@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: S3Presigner)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_s3presigner.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports S3Presigner
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	s3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockS3Presigner is a mock of S3Presigner interface.
+type MockS3Presigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockS3PresignerMockRecorder
+	isgomock struct{}
+}
+
+// MockS3PresignerMockRecorder is the mock recorder for MockS3Presigner.
+type MockS3PresignerMockRecorder struct {
+	mock *MockS3Presigner
+}
+
+// NewMockS3Presigner creates a new mock instance.
+func NewMockS3Presigner(ctrl *gomock.Controller) *MockS3Presigner {
+	mock := &MockS3Presigner{ctrl: ctrl}
+	mock.recorder = &MockS3PresignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockS3Presigner) EXPECT() *MockS3PresignerMockRecorder {
+	return m.recorder
+}
+
+// PresignGetObject mocks base method.
+func (m *MockS3Presigner) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PresignGetObject", varargs...)
+	ret0, _ := ret[0].(*v4.PresignedHTTPRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PresignGetObject indicates an expected call of PresignGetObject.
+func (mr *MockS3PresignerMockRecorder) PresignGetObject(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PresignGetObject", reflect.TypeOf((*MockS3Presigner)(nil).PresignGetObject), varargs...)
+}
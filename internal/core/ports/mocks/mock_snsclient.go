@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: SNSClient)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_snsclient.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports SNSClient
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	sns "github.com/aws/aws-sdk-go-v2/service/sns"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSNSClient is a mock of SNSClient interface.
+type MockSNSClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockSNSClientMockRecorder
+	isgomock struct{}
+}
+
+// MockSNSClientMockRecorder is the mock recorder for MockSNSClient.
+type MockSNSClientMockRecorder struct {
+	mock *MockSNSClient
+}
+
+// NewMockSNSClient creates a new mock instance.
+func NewMockSNSClient(ctrl *gomock.Controller) *MockSNSClient {
+	mock := &MockSNSClient{ctrl: ctrl}
+	mock.recorder = &MockSNSClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSNSClient) EXPECT() *MockSNSClientMockRecorder {
+	return m.recorder
+}
+
+// GetTopicAttributes mocks base method.
+func (m *MockSNSClient) GetTopicAttributes(ctx context.Context, params *sns.GetTopicAttributesInput, optFns ...func(*sns.Options)) (*sns.GetTopicAttributesOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetTopicAttributes", varargs...)
+	ret0, _ := ret[0].(*sns.GetTopicAttributesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTopicAttributes indicates an expected call of GetTopicAttributes.
+func (mr *MockSNSClientMockRecorder) GetTopicAttributes(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopicAttributes", reflect.TypeOf((*MockSNSClient)(nil).GetTopicAttributes), varargs...)
+}
+
+// Publish mocks base method.
+func (m *MockSNSClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Publish", varargs...)
+	ret0, _ := ret[0].(*sns.PublishOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockSNSClientMockRecorder) Publish(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockSNSClient)(nil).Publish), varargs...)
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +42,34 @@ func (m *MockVideoJobRepository) EXPECT() *MockVideoJobRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimJob mocks base method.
+func (m *MockVideoJobRepository) ClaimJob(ctx context.Context, jobID, workerID string, leaseDuration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJob", ctx, jobID, workerID, leaseDuration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimJob indicates an expected call of ClaimJob.
+func (mr *MockVideoJobRepositoryMockRecorder) ClaimJob(ctx, jobID, workerID, leaseDuration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJob", reflect.TypeOf((*MockVideoJobRepository)(nil).ClaimJob), ctx, jobID, workerID, leaseDuration)
+}
+
+// ExtendLease mocks base method.
+func (m *MockVideoJobRepository) ExtendLease(ctx context.Context, jobID, workerID string, leaseDuration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtendLease", ctx, jobID, workerID, leaseDuration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExtendLease indicates an expected call of ExtendLease.
+func (mr *MockVideoJobRepositoryMockRecorder) ExtendLease(ctx, jobID, workerID, leaseDuration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtendLease", reflect.TypeOf((*MockVideoJobRepository)(nil).ExtendLease), ctx, jobID, workerID, leaseDuration)
+}
+
 // GetJobByID mocks base method.
 func (m *MockVideoJobRepository) GetJobByID(ctx context.Context, jobID string) (*domain.VideoJobDTO, error) {
 	m.ctrl.T.Helper()
@@ -56,16 +85,90 @@ func (mr *MockVideoJobRepositoryMockRecorder) GetJobByID(ctx, jobID any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobByID", reflect.TypeOf((*MockVideoJobRepository)(nil).GetJobByID), ctx, jobID)
 }
 
-// UpdateJobStatus mocks base method.
-func (m *MockVideoJobRepository) UpdateJobStatus(ctx context.Context, videoJob *domain.VideoJob) error {
+// ListJobHistory mocks base method.
+func (m *MockVideoJobRepository) ListJobHistory(ctx context.Context, jobID string) ([]domain.JobStatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobHistory", ctx, jobID)
+	ret0, _ := ret[0].([]domain.JobStatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobHistory indicates an expected call of ListJobHistory.
+func (mr *MockVideoJobRepositoryMockRecorder) ListJobHistory(ctx, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobHistory", reflect.TypeOf((*MockVideoJobRepository)(nil).ListJobHistory), ctx, jobID)
+}
+
+// MarkCompleted mocks base method.
+func (m *MockVideoJobRepository) MarkCompleted(ctx context.Context, jobID, workerID, outputPath string, stats domain.JobStats, outbox []domain.OutboxMessage) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkCompleted", ctx, jobID, workerID, outputPath, stats, outbox)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkCompleted indicates an expected call of MarkCompleted.
+func (mr *MockVideoJobRepositoryMockRecorder) MarkCompleted(ctx, jobID, workerID, outputPath, stats, outbox any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkCompleted", reflect.TypeOf((*MockVideoJobRepository)(nil).MarkCompleted), ctx, jobID, workerID, outputPath, stats, outbox)
+}
+
+// MarkFailed mocks base method.
+func (m *MockVideoJobRepository) MarkFailed(ctx context.Context, jobID, workerID string, failure domain.JobFailure, outbox []domain.OutboxMessage) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, jobID, workerID, failure, outbox)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockVideoJobRepositoryMockRecorder) MarkFailed(ctx, jobID, workerID, failure, outbox any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockVideoJobRepository)(nil).MarkFailed), ctx, jobID, workerID, failure, outbox)
+}
+
+// ReleaseExpiredLeases mocks base method.
+func (m *MockVideoJobRepository) ReleaseExpiredLeases(ctx context.Context, workerID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpiredLeases", ctx, workerID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpiredLeases indicates an expected call of ReleaseExpiredLeases.
+func (mr *MockVideoJobRepositoryMockRecorder) ReleaseExpiredLeases(ctx, workerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpiredLeases", reflect.TypeOf((*MockVideoJobRepository)(nil).ReleaseExpiredLeases), ctx, workerID)
+}
+
+// ReleaseJob mocks base method.
+func (m *MockVideoJobRepository) ReleaseJob(ctx context.Context, jobID, workerID, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseJob", ctx, jobID, workerID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseJob indicates an expected call of ReleaseJob.
+func (mr *MockVideoJobRepositoryMockRecorder) ReleaseJob(ctx, jobID, workerID, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseJob", reflect.TypeOf((*MockVideoJobRepository)(nil).ReleaseJob), ctx, jobID, workerID, reason)
+}
+
+// SaveVideoMetadata mocks base method.
+func (m *MockVideoJobRepository) SaveVideoMetadata(ctx context.Context, jobID, workerID string, metadata domain.VideoMetadata) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateJobStatus", ctx, videoJob)
+	ret := m.ctrl.Call(m, "SaveVideoMetadata", ctx, jobID, workerID, metadata)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateJobStatus indicates an expected call of UpdateJobStatus.
-func (mr *MockVideoJobRepositoryMockRecorder) UpdateJobStatus(ctx, videoJob any) *gomock.Call {
+// SaveVideoMetadata indicates an expected call of SaveVideoMetadata.
+func (mr *MockVideoJobRepositoryMockRecorder) SaveVideoMetadata(ctx, jobID, workerID, metadata any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateJobStatus", reflect.TypeOf((*MockVideoJobRepository)(nil).UpdateJobStatus), ctx, videoJob)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveVideoMetadata", reflect.TypeOf((*MockVideoJobRepository)(nil).SaveVideoMetadata), ctx, jobID, workerID, metadata)
 }
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports (interfaces: WorkspaceManager)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_workspacemanager.go -package=mocks github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports WorkspaceManager
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	domain "github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWorkspaceManager is a mock of WorkspaceManager interface.
+type MockWorkspaceManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockWorkspaceManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockWorkspaceManagerMockRecorder is the mock recorder for MockWorkspaceManager.
+type MockWorkspaceManagerMockRecorder struct {
+	mock *MockWorkspaceManager
+}
+
+// NewMockWorkspaceManager creates a new mock instance.
+func NewMockWorkspaceManager(ctrl *gomock.Controller) *MockWorkspaceManager {
+	mock := &MockWorkspaceManager{ctrl: ctrl}
+	mock.recorder = &MockWorkspaceManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWorkspaceManager) EXPECT() *MockWorkspaceManagerMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWorkspaceManager) Create(jobID string) (*domain.Workspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", jobID)
+	ret0, _ := ret[0].(*domain.Workspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWorkspaceManagerMockRecorder) Create(jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWorkspaceManager)(nil).Create), jobID)
+}
+
+// Remove mocks base method.
+func (m *MockWorkspaceManager) Remove(workspace *domain.Workspace) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", workspace)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockWorkspaceManagerMockRecorder) Remove(workspace any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockWorkspaceManager)(nil).Remove), workspace)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+)
+
+const (
+	defaultFPS          = 1
+	maxFPS              = 30
+	maxFrameQuality     = 100
+	maxFrameSize        = 7680
+	defaultFrameQuality = 85
+	defaultFrameFormat  = domain.ImageFormatPNG
+)
+
+// resolveFrameOptions fills in the defaults for the options a job did not
+// set and rejects the ones the processor cannot honour.
+func resolveFrameOptions(opts *domain.FrameOptions) (domain.FrameOptions, error) {
+	var resolved domain.FrameOptions
+	if opts != nil {
+		resolved = *opts
+	}
+
+	if resolved.FPS != 0 && resolved.IntervalSeconds != 0 {
+		return resolved, fmt.Errorf("%w: fps and interval_seconds cannot be combined", domain.ErrInvalidFrameOptions)
+	}
+	if resolved.FPS < 0 || resolved.FPS > maxFPS {
+		return resolved, fmt.Errorf("%w: fps must be between 0 and %d", domain.ErrInvalidFrameOptions, maxFPS)
+	}
+	if resolved.IntervalSeconds < 0 {
+		return resolved, fmt.Errorf("%w: interval_seconds must be positive", domain.ErrInvalidFrameOptions)
+	}
+	// A shorter interval is a rate above the fps limit.
+	if resolved.IntervalSeconds > 0 && resolved.IntervalSeconds < 1.0/maxFPS {
+		return resolved, fmt.Errorf("%w: interval_seconds must be at least 1/%d", domain.ErrInvalidFrameOptions, maxFPS)
+	}
+	if resolved.FPS == 0 && resolved.IntervalSeconds == 0 {
+		resolved.FPS = defaultFPS
+	}
+
+	switch resolved.Format {
+	case "":
+		resolved.Format = defaultFrameFormat
+	case domain.ImageFormatPNG, domain.ImageFormatJPG, domain.ImageFormatWEBP:
+	default:
+		return resolved, fmt.Errorf("%w: unsupported format '%s'", domain.ErrInvalidFrameOptions, resolved.Format)
+	}
+
+	if resolved.Quality < 0 || resolved.Quality > maxFrameQuality {
+		return resolved, fmt.Errorf("%w: quality must be between 1 and %d", domain.ErrInvalidFrameOptions, maxFrameQuality)
+	}
+	if resolved.Quality == 0 && resolved.Format != domain.ImageFormatPNG {
+		resolved.Quality = defaultFrameQuality
+	}
+
+	if resolved.MaxWidth < 0 || resolved.MaxWidth > maxFrameSize || resolved.MaxHeight < 0 || resolved.MaxHeight > maxFrameSize {
+		return resolved, fmt.Errorf("%w: max_width and max_height must be between 0 and %d", domain.ErrInvalidFrameOptions, maxFrameSize)
+	}
+
+	if resolved.StartSeconds < 0 || resolved.EndSeconds < 0 {
+		return resolved, fmt.Errorf("%w: start_seconds and end_seconds must be positive", domain.ErrInvalidFrameOptions)
+	}
+	if resolved.EndSeconds != 0 && resolved.EndSeconds <= resolved.StartSeconds {
+		return resolved, fmt.Errorf("%w: end_seconds must be after start_seconds", domain.ErrInvalidFrameOptions)
+	}
+
+	return resolved, nil
+}
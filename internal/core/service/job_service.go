@@ -2,98 +2,552 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"time"
 
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
-	"github.com/samber/lo"
-	"gorm.io/gorm"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/service")
+
+// notifyTimeout bounds how long a finished job waits for its user to be
+// notified.
+const notifyTimeout = 30 * time.Second
+
+// publishTimeout bounds how long a job waits for a lifecycle event to be
+// published.
+const publishTimeout = 10 * time.Second
+
+// releaseTimeout bounds how long a job given up for a retry waits for its
+// lease to be released.
+const releaseTimeout = 10 * time.Second
+
+type Config struct {
+	// MaxAttempts is how many times a job is tried before a retryable failure
+	// is treated as permanent.
+	MaxAttempts int
+	// WorkerID identifies this worker in the job status history and as the
+	// owner of job leases.
+	WorkerID string
+	// LeaseDuration is how long a claimed job stays reserved for this worker
+	// without being renewed. It must be positive, or the claimed job's lease
+	// has already expired and the reaper re-queues it.
+	LeaseDuration time.Duration
+	// Limits rejects input videos the worker should not process.
+	Limits VideoLimits
+}
+
+// VideoLimits bound the input videos a job accepts. Zero disables a limit.
+type VideoLimits struct {
+	MaxDuration time.Duration
+	MaxSize     int64
+	MaxWidth    int
+	MaxHeight   int
+}
+
 type JobService struct {
-	repo      ports.VideoJobRepository
-	storage   ports.S3Adapter
-	processor ports.ProcessorAdapter
-	errorPub  ports.SQSAdapter
+	repo       ports.VideoJobRepository
+	storage    ports.S3Adapter
+	processor  ports.ProcessorAdapter
+	events     ports.EventPublisher
+	notifier   ports.Notifier
+	workspaces ports.WorkspaceManager
+	metrics    ports.MetricsRecorder
+	cfg        Config
 }
 
 func NewJobService(
 	repo ports.VideoJobRepository,
 	storage ports.S3Adapter,
 	processor ports.ProcessorAdapter,
-	errorPub ports.SQSAdapter,
+	events ports.EventPublisher,
+	notifier ports.Notifier,
+	workspaces ports.WorkspaceManager,
+	metrics ports.MetricsRecorder,
+	cfg Config,
 ) *JobService {
 	return &JobService{
-		repo:      repo,
-		storage:   storage,
-		processor: processor,
-		errorPub:  errorPub,
+		repo:       repo,
+		storage:    storage,
+		processor:  processor,
+		events:     events,
+		notifier:   notifier,
+		workspaces: workspaces,
+		metrics:    metrics,
+		cfg:        cfg,
 	}
 }
 
-func (s *JobService) ProcessJob(ctx context.Context, jobID string) error {
-	log.Printf("[Job %s] Starting processing for video", jobID)
+// ProcessJob runs a job end to end. Returned errors are classified with
+// domain.RetryableError or domain.PermanentError so the caller knows whether
+// the message should be redelivered. attempt is 1 on the first delivery.
+func (s *JobService) ProcessJob(ctx context.Context, event domain.JobMessageEvent, attempt int) error {
+	ctx, span := tracer.Start(ctx, "ProcessJob", trace.WithAttributes(
+		attribute.String("job.id", event.JobID),
+		attribute.Int("job.attempt", attempt),
+	))
+	err := s.processJob(ctx, event, attempt)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *JobService) processJob(ctx context.Context, event domain.JobMessageEvent, attempt int) error {
+	jobID := event.JobID
+	started := time.Now()
+	log := logger.FromContext(ctx)
+	log.Info("Starting processing for video.", "attempt", attempt, "max_attempts", s.cfg.MaxAttempts)
 
-	job, err := s.repo.GetJobByID(ctx, jobID)
+	spanCtx, span := tracer.Start(ctx, "db.get_job")
+	job, err := s.repo.GetJobByID(spanCtx, jobID)
+	tracing.End(span, err)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("ERROR: [Job %s] Job not found in DB. Message discarded.", jobID)
+		if errors.Is(err, domain.ErrJobNotFound) {
+			log.Error("Job not found in DB. Message discarded.")
 			return nil
 		}
-		return fmt.Errorf("job %s: failed to fetch job details: %w", jobID, err)
+		s.metrics.JobFailed(domain.FailureDatabase, true)
+		return domain.NewRetryableError(fmt.Errorf("job %s: failed to fetch job details: %w", jobID, err))
+	}
+
+	ctx, log = logger.With(ctx, logger.UserIDKey, job.UserID)
+
+	if !job.Status.CanTransitionTo(domain.VideoStatusProcessing) {
+		log.Info("Job is already finished or in progress. Duplicate message discarded.", "status", job.Status)
+		return nil
+	}
+
+	spanCtx, span = tracer.Start(ctx, "db.claim_job")
+	err = s.repo.ClaimJob(spanCtx, jobID, s.cfg.WorkerID, s.cfg.LeaseDuration)
+	tracing.End(span, err)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			log.Info("Job was processed by another worker. Duplicate message discarded.", "error", err)
+			return nil
+		}
+		if errors.Is(err, domain.ErrJobAlreadyClaimed) {
+			// The lease may belong to a worker that crashed, so the message is
+			// kept until the job either finishes or is released.
+			log.Info("Job is leased by another worker. Message left for redelivery.", "error", err)
+			return domain.NewRetryableError(fmt.Errorf("job %s: %w", jobID, err))
+		}
+		s.metrics.JobFailed(domain.FailureDatabase, true)
+		return domain.NewRetryableError(fmt.Errorf("job %s: failed to claim job: %w", jobID, err))
+	}
+
+	stopLease := s.keepLease(ctx, jobID)
+	defer stopLease()
+	s.publish(ctx, domain.JobStartedEvent{JobEventHeader: s.eventHeader(job, domain.JobEventStarted), Attempt: attempt})
+
+	opts, err := resolveFrameOptions(event.Options)
+	if err != nil {
+		return s.fail(ctx, job, attempt, domain.StagePrepare, domain.FailureInvalidOptions, domain.NewPermanentError(fmt.Errorf("job %s: %w", jobID, err)))
+	}
+
+	workspace, err := s.workspaces.Create(jobID)
+	if err != nil {
+		return s.fail(ctx, job, attempt, domain.StagePrepare, domain.FailureWorkspace, domain.NewRetryableError(fmt.Errorf("job %s: %w", jobID, err)))
+	}
+	defer s.removeWorkspace(ctx, workspace)
+
+	s.publishProgress(ctx, job, domain.StageDownload)
+	stageStarted := time.Now()
+	spanCtx, span = tracer.Start(ctx, "s3.download", trace.WithAttributes(attribute.String("s3.key", job.VideoPath)))
+	tempVideoFile, err := s.storage.DownloadFile(spanCtx, job.VideoPath, workspace)
+	tracing.End(span, err)
+	if err != nil {
+		err = fmt.Errorf("job %s: failed to download video from S3: %w", jobID, err)
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			return s.fail(ctx, job, attempt, domain.StageDownload, domain.FailureVideoNotFound, domain.NewPermanentError(err))
+		}
+		// Anything else, a corrupt download included, may succeed next time.
+		return s.fail(ctx, job, attempt, domain.StageDownload, domain.FailureDownload, domain.NewRetryableError(err))
 	}
+	s.metrics.ObserveStage("download", time.Since(stageStarted))
+	s.metrics.AddBytesDownloaded(tempVideoFile.SizeBytes)
 
-	if err := s.setStatus(ctx, job, domain.VideoStatusProcessing); err != nil {
-		return fmt.Errorf("job %s: failed to update status to 'processing': %w", jobID, err)
+	s.publishProgress(ctx, job, domain.StageProbe)
+	stageStarted = time.Now()
+	spanCtx, span = tracer.Start(ctx, "ffprobe")
+	metadata, err := s.processor.ProbeVideo(spanCtx, tempVideoFile.Path)
+	tracing.End(span, err)
+	if err != nil {
+		err = fmt.Errorf("job %s: failed to probe video: %w", jobID, err)
+		if errors.Is(err, domain.ErrInvalidVideo) {
+			return s.fail(ctx, job, attempt, domain.StageProbe, domain.FailureInvalidVideo, domain.NewPermanentError(err))
+		}
+		return s.fail(ctx, job, attempt, domain.StageProbe, domain.FailureProbe, domain.NewRetryableError(err))
+	}
+	s.metrics.ObserveStage("probe", time.Since(stageStarted))
+	spanCtx, span = tracer.Start(ctx, "db.save_metadata")
+	err = s.repo.SaveVideoMetadata(spanCtx, jobID, s.cfg.WorkerID, *metadata)
+	tracing.End(span, err)
+	if err != nil {
+		return s.fail(ctx, job, attempt, domain.StageProbe, domain.FailureDatabase, domain.NewRetryableError(fmt.Errorf("job %s: failed to save video metadata: %w", jobID, err)))
+	}
+	if err := s.cfg.Limits.check(metadata); err != nil {
+		return s.fail(ctx, job, attempt, domain.StageProbe, domain.FailureLimitExceeded, domain.NewPermanentError(fmt.Errorf("job %s: %w", jobID, err)))
 	}
 
-	tempVideoFile, err := s.storage.DownloadFile(ctx, job.VideoPath)
+	s.publishProgress(ctx, job, domain.StageExtract)
+	outputPath := fmt.Sprintf("output/%s.zip", jobID)
+	archive, err := s.extractAndUpload(ctx, tempVideoFile.Path, opts, outputPath)
 	if err != nil {
-		s.failJob(ctx, job)
-		return fmt.Errorf("job %s: failed to download video from S3: %w", jobID, err)
+		stage, reason := domain.StageExtract, domain.FailureProcessing
+		if domain.IsRetryable(err) {
+			stage, reason = domain.StageUpload, domain.FailureUpload
+		}
+		return s.fail(ctx, job, attempt, stage, reason, fmt.Errorf("job %s: %w", jobID, err))
 	}
 
-	localZipPath, zipName, err := s.processor.Process(ctx, tempVideoFile.Path)
+	// The archive is already uploaded, so a job without a download link is
+	// still completed; the link can be generated again from output_path.
+	download, err := s.storage.PresignDownload(ctx, outputPath)
 	if err != nil {
-		s.failJob(ctx, job)
-		return fmt.Errorf("job %s: failed to process video: %w", jobID, err)
+		log.Warn("Failed to generate the download link.", "error", err)
 	}
 
-	outputPath := fmt.Sprintf("output/%s", zipName)
-	if err := s.storage.UploadFile(ctx, localZipPath, outputPath); err != nil {
-		s.failJob(ctx, job)
-		return fmt.Errorf("job %s: failed to upload processed video to S3: %w", jobID, err)
+	duration := time.Since(started)
+	completed := domain.JobCompletedEvent{
+		JobEventHeader:    s.eventHeader(job, domain.JobEventCompleted),
+		OutputPath:        outputPath,
+		FrameCount:        archive.FrameCount,
+		ArchiveSizeBytes:  archive.SizeBytes,
+		DurationMs:        duration.Milliseconds(),
+		ExtractDurationMs: archive.ExtractDuration.Milliseconds(),
+		ZipDurationMs:     archive.ZipDuration.Milliseconds(),
+	}
+	if download != nil {
+		completed.DownloadURL = download.URL
+		completed.DownloadURLExpiresAt = &download.ExpiresAt
+	}
+	outbox, err := newOutboxMessages(ctx, job.ID, outboxEntry{domain.OutboxEvents, string(domain.JobEventCompleted), completed})
+	if err != nil {
+		s.metrics.JobFailed(domain.FailureDatabase, true)
+		return domain.NewRetryableError(fmt.Errorf("job %s: job completed, but %w", jobID, err))
 	}
 
-	job.OutputPath = lo.ToPtr(outputPath)
-	if err := s.setStatus(ctx, job, domain.VideoStatusCompleted); err != nil {
-		return fmt.Errorf("job %s: job completed, but failed to update final status: %w", job.ID, err)
+	spanCtx, span = tracer.Start(ctx, "db.mark_completed")
+	updated, err := s.repo.MarkCompleted(spanCtx, jobID, s.cfg.WorkerID, outputPath, domain.JobStats{
+		FrameCount:  archive.FrameCount,
+		ArchiveSize: archive.SizeBytes,
+		Download:    download,
+	}, outbox)
+	tracing.End(span, err)
+	if err != nil {
+		s.metrics.JobFailed(domain.FailureDatabase, true)
+		return domain.NewRetryableError(fmt.Errorf("job %s: job completed, but failed to update final status: %w", jobID, err))
+	}
+	if updated == 0 {
+		if s.requeued(ctx, jobID) {
+			s.metrics.JobFailed(domain.FailureStatusConflict, true)
+			return domain.NewRetryableError(fmt.Errorf("job %s: job completed, but it was re-queued after its lease was lost: %w", jobID, domain.ErrStatusConflict))
+		}
+		s.metrics.JobFailed(domain.FailureStatusConflict, false)
+		return domain.NewPermanentError(fmt.Errorf("job %s: job completed, but its status was changed by another worker: %w", jobID, domain.ErrStatusConflict))
 	}
 
-	log.Printf("[Job %s] Processing completed successfully.", jobID)
+	s.metrics.JobSucceeded(duration)
+	log.Info("Processing completed successfully.", "frames", archive.FrameCount, "archive_bytes", archive.SizeBytes)
+	s.notify(ctx, func(ctx context.Context) error {
+		return s.notifier.JobCompleted(ctx, job, download)
+	})
 	return nil
 }
 
-func (s *JobService) failJob(ctx context.Context, job *domain.VideoJobDTO) {
-	log.Printf("[Job %s] ERROR: failed to process video", job.ID)
-	_ = s.setStatus(ctx, job, domain.VideoStatusFailed)
-	event := domain.JobErrorEvent{
-		JobID: job.ID,
+// requeued tells whether a job whose status could not be updated went back to
+// queued, as the lease reaper does once this worker's lease expires. No message
+// is left to claim such a job, so the current one must be redelivered. A job
+// that can't be read is treated the same way.
+func (s *JobService) requeued(ctx context.Context, jobID string) bool {
+	spanCtx, span := tracer.Start(ctx, "db.get_job")
+	job, err := s.repo.GetJobByID(spanCtx, jobID)
+	tracing.End(span, err)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to read the job status.", "error", err)
+		return true
+	}
+	return job.Status == domain.VideoStatusQueued
+}
+
+// notify sends a notification about a job that already ended. Failing to
+// notify is logged but never changes the outcome of the job, and a worker
+// shutting down still sends it.
+func (s *JobService) notify(ctx context.Context, send func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	spanCtx, span := tracer.Start(ctx, "notify")
+	err := send(spanCtx)
+	tracing.End(span, err)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to notify user.", "error", err)
 	}
-	if err := s.errorPub.Publish(ctx, event); err != nil {
-		log.Printf("CRITICAL ERROR: [Job %s] Failed to publish to error queue: %v", job.ID, err)
+}
+
+// publish sends a lifecycle event right away. It is meant for the started and
+// progress events, which are informative only: failing to publish is logged
+// and otherwise ignored. The events announcing how a job ended go through the
+// outbox instead.
+func (s *JobService) publish(ctx context.Context, event domain.JobEvent) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+	eventType := event.Header().Type
+	spanCtx, span := tracer.Start(ctx, "events.publish", trace.WithAttributes(attribute.String("event.type", string(eventType))))
+	err := s.events.Publish(spanCtx, event)
+	tracing.End(span, err)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to publish job event.", "event_type", eventType, "error", err)
+	}
+}
+
+func (s *JobService) publishProgress(ctx context.Context, job *domain.VideoJobDTO, stage domain.JobStage) {
+	s.publish(ctx, domain.JobProgressEvent{JobEventHeader: s.eventHeader(job, domain.JobEventProgress), Stage: stage})
+}
+
+// outboxEntry is an event to write to the outbox along with a status change.
+type outboxEntry struct {
+	destination domain.OutboxDestination
+	eventType   string
+	event       any
+}
+
+// newOutboxMessages serializes the events for the outbox, along with the
+// trace context of ctx so the relay continues the job's trace.
+func newOutboxMessages(ctx context.Context, jobID string, entries ...outboxEntry) ([]domain.OutboxMessage, error) {
+	headers := domain.OutboxHeaders{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	messages := make([]domain.OutboxMessage, 0, len(entries))
+	for _, entry := range entries {
+		payload, err := json.Marshal(entry.event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize '%s' event: %w", entry.eventType, err)
+		}
+		messages = append(messages, domain.OutboxMessage{
+			JobID:       jobID,
+			Destination: entry.destination,
+			EventType:   entry.eventType,
+			Payload:     string(payload),
+			Headers:     headers,
+			AvailableAt: time.Now(),
+		})
 	}
+	return messages, nil
 }
 
-func (s *JobService) setStatus(ctx context.Context, job *domain.VideoJobDTO, status domain.VideoStatus) error {
-	return s.repo.UpdateJobStatus(ctx, &domain.VideoJob{
-		ID:         job.ID,
-		Status:     status,
-		CreatedAt:  job.CreatedAt,
-		OutputPath: job.OutputPath,
+func (s *JobService) eventHeader(job *domain.VideoJobDTO, eventType domain.JobEventType) domain.JobEventHeader {
+	return domain.JobEventHeader{
+		Type:       eventType,
+		JobID:      job.ID,
 		UserID:     job.UserID,
-		VideoPath:  job.VideoPath,
+		WorkerID:   s.cfg.WorkerID,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// extractAndUpload pipes the archive the processor writes straight into the
+// storage upload, so the zip never has to be stored locally.
+func (s *JobService) extractAndUpload(ctx context.Context, localVideoPath string, opts domain.FrameOptions, outputPath string) (*domain.ProcessedArchive, error) {
+	pr, pw := io.Pipe()
+	uploaded := make(chan error, 1)
+	started := time.Now()
+	uploadCtx, uploadSpan := tracer.Start(ctx, "s3.upload", trace.WithAttributes(attribute.String("s3.key", outputPath)))
+	go func() {
+		err := s.storage.UploadStream(uploadCtx, outputPath, pr)
+		// Unblocks the processor if the upload stopped reading early.
+		pr.CloseWithError(err)
+		uploaded <- err
+	}()
+
+	processCtx, processSpan := tracer.Start(ctx, "ffmpeg")
+	archive, processErr := s.processor.Process(processCtx, localVideoPath, opts, pw)
+	tracing.End(processSpan, processErr)
+	// A nil error ends the stream normally; otherwise the upload is aborted.
+	pw.CloseWithError(processErr)
+	uploadErr := <-uploaded
+	uploadDuration := time.Since(started)
+	tracing.End(uploadSpan, uploadErr)
+
+	// When processing failed the upload only fails because its input was
+	// cut off, so the processor error is the one worth reporting.
+	if uploadErr != nil && (processErr == nil || !errors.Is(uploadErr, processErr)) {
+		return nil, domain.NewRetryableError(fmt.Errorf("failed to upload processed video to S3: %w", uploadErr))
+	}
+	if processErr != nil {
+		return nil, domain.NewPermanentError(fmt.Errorf("failed to process video: %w", processErr))
+	}
+
+	// ffmpeg, zip and upload run at the same time, so their durations overlap.
+	s.metrics.ObserveStage("ffmpeg", archive.ExtractDuration)
+	s.metrics.ObserveStage("zip", archive.ZipDuration)
+	s.metrics.ObserveStage("upload", uploadDuration)
+	s.metrics.AddBytesUploaded(archive.SizeBytes)
+	return archive, nil
+}
+
+func (l VideoLimits) check(metadata *domain.VideoMetadata) error {
+	duration := time.Duration(metadata.DurationSeconds * float64(time.Second))
+	switch {
+	case l.MaxDuration > 0 && duration > l.MaxDuration:
+		return fmt.Errorf("%w: duration %s is above the %s limit", domain.ErrVideoLimitExceeded, duration.Round(time.Second), l.MaxDuration)
+	case l.MaxSize > 0 && metadata.SizeBytes > l.MaxSize:
+		return fmt.Errorf("%w: size of %d bytes is above the %d bytes limit", domain.ErrVideoLimitExceeded, metadata.SizeBytes, l.MaxSize)
+	case l.MaxWidth > 0 && metadata.Width > l.MaxWidth, l.MaxHeight > 0 && metadata.Height > l.MaxHeight:
+		return fmt.Errorf("%w: resolution %dx%d is above the %dx%d limit", domain.ErrVideoLimitExceeded, metadata.Width, metadata.Height, l.MaxWidth, l.MaxHeight)
+	}
+	return nil
+}
+
+// removeWorkspace deletes the job's intermediate files whether it succeeded
+// or failed. A failure only leaks disk until the next startup sweep.
+func (s *JobService) removeWorkspace(ctx context.Context, workspace *domain.Workspace) {
+	if err := s.workspaces.Remove(workspace); err != nil {
+		logger.FromContext(ctx).Warn("Failed to remove workspace.", "dir", workspace.Dir, "error", err)
+	}
+}
+
+// keepLease renews the job lease in the background until the returned
+// function is called.
+func (s *JobService) keepLease(ctx context.Context, jobID string) func() {
+	if s.cfg.LeaseDuration <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.cfg.LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.repo.ExtendLease(ctx, jobID, s.cfg.WorkerID, s.cfg.LeaseDuration); err != nil && ctx.Err() == nil {
+					logger.FromContext(ctx).Warn("Failed to extend job lease.", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// fail decides what happens after a step failed. Retryable errors are
+// returned untouched while attempts remain so the message is redelivered, and
+// the job is released for the redelivery to claim; everything else marks the
+// job as failed and is returned as permanent.
+// reason labels the failure in the metrics and in the user notification, and
+// stage is where it happened unless err says more precisely.
+func (s *JobService) fail(ctx context.Context, job *domain.VideoJobDTO, attempt int, stage domain.JobStage, reason domain.FailureReason, err error) error {
+	log := logger.FromContext(ctx)
+	if ctx.Err() != nil {
+		// The worker is shutting down: keep the job as is so the redelivered
+		// message can pick it up again.
+		log.Warn("Processing interrupted.", "error", ctx.Err())
+		s.metrics.JobFailed(reason, true)
+		s.releaseJob(ctx, job, "processing interrupted")
+		return domain.NewRetryableError(err)
+	}
+
+	if domain.IsRetryable(err) && attempt < s.cfg.MaxAttempts {
+		log.Warn("Attempt failed, job will be retried.", "attempt", attempt, "max_attempts", s.cfg.MaxAttempts, "stage", stage, "reason", reason, "error", err)
+		s.metrics.JobFailed(reason, true)
+		s.releaseJob(ctx, job, fmt.Sprintf("attempt %d failed: %s", attempt, reason))
+		return err
+	}
+
+	s.metrics.JobFailed(reason, false)
+	if recordErr := s.failJob(ctx, job, attempt, newJobFailure(stage, reason, err)); recordErr != nil {
+		// The redelivered message records the failure again, along with its
+		// events.
+		return domain.NewRetryableError(fmt.Errorf("%w (failed to record the failure: %v)", err, recordErr))
+	}
+	return domain.NewPermanentError(err)
+}
+
+// releaseJob puts the job back to queued so the redelivered message can claim
+// it before the lease expires. If that fails the job waits for the lease
+// reaper instead.
+func (s *JobService) releaseJob(ctx context.Context, job *domain.VideoJobDTO, reason string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+	spanCtx, span := tracer.Start(ctx, "db.release_job")
+	err := s.repo.ReleaseJob(spanCtx, job.ID, s.cfg.WorkerID, reason)
+	tracing.End(span, err)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to release job for the retry. It will be released once its lease expires.", "error", err)
+	}
+}
+
+// newJobFailure describes a failure, preferring the stage and tool output a
+// processor attached to err.
+func newJobFailure(stage domain.JobStage, reason domain.FailureReason, err error) domain.JobFailure {
+	failure := domain.JobFailure{Code: reason, Stage: stage, Message: err.Error()}
+	var stageErr *domain.StageError
+	if errors.As(err, &stageErr) {
+		failure.Stage = stageErr.Stage
+		failure.Stderr = stageErr.Stderr
+	}
+	return failure
+}
+
+// failJob records the failure and, in the same transaction, the error and
+// failed events the outbox relay publishes afterwards. It returns the error
+// of a failure that couldn't be recorded.
+func (s *JobService) failJob(ctx context.Context, job *domain.VideoJobDTO, attempt int, failure domain.JobFailure) error {
+	log := logger.FromContext(ctx)
+	log.Error("Failed to process video.", "reason", failure.Code, "stage", failure.Stage, "error", failure.Message, "stderr", failure.Stderr)
+	errorEvent := domain.JobErrorEvent{
+		JobID:      job.ID,
+		Code:       failure.Code,
+		Stage:      failure.Stage,
+		Message:    failure.Message,
+		Stderr:     failure.Stderr,
+		Attempt:    attempt,
+		WorkerID:   s.cfg.WorkerID,
+		OccurredAt: time.Now().UTC(),
+	}
+	failedEvent := domain.JobFailedEvent{JobEventHeader: s.eventHeader(job, domain.JobEventFailed), Reason: failure.Code, Stage: failure.Stage, Attempt: attempt}
+	outbox, err := newOutboxMessages(ctx, job.ID,
+		outboxEntry{domain.OutboxErrorQueue, domain.JobErrorEventType, errorEvent},
+		outboxEntry{domain.OutboxEvents, string(domain.JobEventFailed), failedEvent},
+	)
+	if err != nil {
+		log.Error("Failed to write job events to the outbox.", "error", err)
+	}
+
+	spanCtx, span := tracer.Start(ctx, "db.mark_failed")
+	updated, err := s.repo.MarkFailed(spanCtx, job.ID, s.cfg.WorkerID, failure, outbox)
+	tracing.End(span, err)
+	if err != nil {
+		log.Error("Failed to update status to 'failed'.", "error", err)
+		return err
+	}
+	if updated == 0 {
+		if s.requeued(ctx, job.ID) {
+			log.Warn("Job was re-queued after its lease was lost. Failure not recorded yet.")
+			return domain.ErrStatusConflict
+		}
+		log.Warn("Job status was changed by another worker. Failure not recorded.")
+		return nil
+	}
+
+	s.notify(ctx, func(ctx context.Context) error {
+		return s.notifier.JobFailed(ctx, job, failure.Code)
 	})
+	return nil
 }
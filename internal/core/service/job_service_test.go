@@ -1,28 +1,82 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/repository"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
 	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/service"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/mock/gomock"
-	"gorm.io/gorm"
 )
 
 type jobServiceTestSuite struct {
 	suite.Suite
 
-	ctx           context.Context
-	mockRepo      *mocks.MockVideoJobRepository
-	mockStorage   *mocks.MockS3Adapter
-	mockProcessor *mocks.MockProcessorAdapter
-	mockErrorPub  *mocks.MockSQSAdapter
-	jobService    *service.JobService
+	ctx            context.Context
+	mockRepo       *mocks.MockVideoJobRepository
+	mockStorage    *mocks.MockS3Adapter
+	mockProcessor  *mocks.MockProcessorAdapter
+	mockEvents     *mocks.MockEventPublisher
+	mockWorkspaces *mocks.MockWorkspaceManager
+	mockNotifier   *mocks.MockNotifier
+	mockMetrics    *mocks.MockMetricsRecorder
+	jobService     *service.JobService
+}
+
+var (
+	defaultFrameOptions = domain.FrameOptions{FPS: 1, Format: domain.ImageFormatPNG}
+	testWorkspace       = &domain.Workspace{JobID: "job-123", Dir: "/tmp/process-worker/job-123-1"}
+	validMetadata       = &domain.VideoMetadata{
+		FormatName:      "mov,mp4,m4a,3gp,3g2,mj2",
+		DurationSeconds: 60,
+		SizeBytes:       10 << 20,
+		VideoCodec:      "h264",
+		Width:           1920,
+		Height:          1080,
+		FrameRate:       30,
+	}
+	testDownload = &domain.DownloadLink{
+		URL:       "https://bucket-videos.s3.amazonaws.com/output/job-123.zip?X-Amz-Signature=abc",
+		ExpiresAt: time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+// drainUpload reads the whole archive like a real upload would, failing when
+// the processor aborts the stream.
+func drainUpload(_ context.Context, _ string, body io.Reader) error {
+	_, err := io.ReadAll(body)
+	return err
+}
+
+// allowMetrics accepts any metric, for tests that don't check them.
+func allowMetrics(m *mocks.MockMetricsRecorder) {
+	m.EXPECT().JobReceived().AnyTimes()
+	m.EXPECT().JobStarted().AnyTimes()
+	m.EXPECT().JobFinished().AnyTimes()
+	m.EXPECT().JobSucceeded(gomock.Any()).AnyTimes()
+	m.EXPECT().JobFailed(gomock.Any(), gomock.Any()).AnyTimes()
+	m.EXPECT().ObserveStage(gomock.Any(), gomock.Any()).AnyTimes()
+	m.EXPECT().AddBytesDownloaded(gomock.Any()).AnyTimes()
+	m.EXPECT().AddBytesUploaded(gomock.Any()).AnyTimes()
+	m.EXPECT().ReceiveFailed().AnyTimes()
 }
 
 func (sts *jobServiceTestSuite) BeforeTest(_, _ string) {
@@ -31,12 +85,23 @@ func (sts *jobServiceTestSuite) BeforeTest(_, _ string) {
 	sts.mockRepo = mocks.NewMockVideoJobRepository(ctrl)
 	sts.mockStorage = mocks.NewMockS3Adapter(ctrl)
 	sts.mockProcessor = mocks.NewMockProcessorAdapter(ctrl)
-	sts.mockErrorPub = mocks.NewMockSQSAdapter(ctrl)
+	sts.mockEvents = mocks.NewMockEventPublisher(ctrl)
+	sts.mockEvents.EXPECT().Publish(gomock.Any(), gomock.Any()).AnyTimes()
+	sts.mockWorkspaces = mocks.NewMockWorkspaceManager(ctrl)
+	sts.mockNotifier = mocks.NewMockNotifier(ctrl)
+	sts.mockNotifier.EXPECT().JobCompleted(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	sts.mockNotifier.EXPECT().JobFailed(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	sts.mockMetrics = mocks.NewMockMetricsRecorder(ctrl)
+	allowMetrics(sts.mockMetrics)
 	sts.jobService = service.NewJobService(
 		sts.mockRepo,
 		sts.mockStorage,
 		sts.mockProcessor,
-		sts.mockErrorPub,
+		sts.mockEvents,
+		sts.mockNotifier,
+		sts.mockWorkspaces,
+		sts.mockMetrics,
+		service.Config{MaxAttempts: 3, WorkerID: "worker-1", LeaseDuration: time.Minute},
 	)
 }
 
@@ -53,34 +118,42 @@ func (sts *jobServiceTestSuite) Test_ProcessJob_Sucess() {
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "started",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 		}
 
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, j *domain.VideoJob) error {
-			j.Status = domain.VideoStatusProcessing
-			return nil
-		})
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
 
-		sts.mockStorage.EXPECT().DownloadFile(sts.ctx, videoPath).Return(&domain.DownloadedFile{
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{
 			Path: "/testdata/downloadFile/trailerGTA6_4k.mp4",
-			File: nil,
 		}, nil)
 
-		sts.mockProcessor.EXPECT().Process(sts.ctx, "/testdata/downloadFile/trailerGTA6_4k.mp4").Return("/testdata/processed/trailerGTA6_4k.zip", "trailerGTA6_4k.zip", nil)
-		sts.mockStorage.EXPECT().UploadFile(sts.ctx, "/testdata/processed/trailerGTA6_4k.zip", "output/trailerGTA6_4k.zip").Return(nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, j *domain.VideoJob) error {
-			j.Status = domain.VideoStatusCompleted
-			j.OutputPath = lo.ToPtr("s3://donwload/trailerGTA6_4k.zip")
-			return nil
-		})
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/testdata/downloadFile/trailerGTA6_4k.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/testdata/downloadFile/trailerGTA6_4k.mp4", defaultFrameOptions, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ domain.FrameOptions, output io.Writer) (*domain.ProcessedArchive, error) {
+				_, err := output.Write([]byte("zip content"))
+				return &domain.ProcessedArchive{FrameCount: 120, SizeBytes: 2048}, err
+			})
+		var uploaded []byte
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, body io.Reader) error {
+				var err error
+				uploaded, err = io.ReadAll(body)
+				return err
+			})
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", domain.JobStats{FrameCount: 120, ArchiveSize: 2048, Download: testDownload}, gomock.Any()).Return(int64(1), nil)
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.NoError(err, "expected no error when processing job")
+		sts.Equal("zip content", string(uploaded), "expected the archive to be streamed to S3")
 
 	})
 
@@ -92,9 +165,9 @@ func (sts *jobServiceTestSuite) Test_ProcessJob_Errors() {
 	s.Run("should return nil if job not found in DB", func(t *testing.T) {
 		jobID := "job-404"
 
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(nil, gorm.ErrRecordNotFound)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(nil, fmt.Errorf("job with id '%s' not found: %w", jobID, domain.ErrJobNotFound))
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.NoError(err, "expected no error when job is not found")
 	})
@@ -103,52 +176,53 @@ func (sts *jobServiceTestSuite) Test_ProcessJob_Errors() {
 		jobID := "job-err"
 		expectedErr := errors.New("db error")
 
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(nil, expectedErr)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(nil, expectedErr)
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.Error(err)
 		sts.Contains(err.Error(), "failed to fetch job details")
 	})
 
-	s.Run("should return error if setStatus to processing fails", func(t *testing.T) {
+	s.Run("should return error if claiming the job fails", func(t *testing.T) {
 		jobID := "job-123"
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "started",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 		}
 		expectedErr := errors.New("update status error")
 
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(expectedErr)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(expectedErr)
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.Error(err)
-		sts.Contains(err.Error(), "failed to update status to 'processing'")
+		sts.Contains(err.Error(), "failed to claim job")
 	})
 
-	s.Run("should return error if DownloadFile fails", func(t *testing.T) {
+	s.Run("should fail job and return error if DownloadFile fails on the last attempt", func(t *testing.T) {
 		jobID := "job-123"
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "started",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 			Email:     "user@email.com",
 		}
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockStorage.EXPECT().DownloadFile(sts.ctx, videoPath).Return(nil, errors.New("download error"))
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockErrorPub.EXPECT().Publish(sts.ctx, gomock.Any()).Return(nil)
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(nil, errors.New("download error"))
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 3)
 
 		sts.Error(err)
 		sts.Contains(err.Error(), "failed to download video from S3")
@@ -159,51 +233,57 @@ func (sts *jobServiceTestSuite) Test_ProcessJob_Errors() {
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 			Email:     "user@email.com",
 		}
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockStorage.EXPECT().DownloadFile(sts.ctx, videoPath).Return(&domain.DownloadedFile{
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{
 			Path: "/tmp/video.mp4",
-			File: nil,
 		}, nil)
-		sts.mockProcessor.EXPECT().Process(sts.ctx, "/tmp/video.mp4").Return("", "", errors.New("process error"))
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockErrorPub.EXPECT().Publish(sts.ctx, gomock.Any()).Return(nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(nil, errors.New("process error"))
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.Error(err)
 		sts.Contains(err.Error(), "failed to process video")
+		sts.False(domain.IsRetryable(err))
 	})
 
-	s.Run("should fail job and return error if upload fails", func(t *testing.T) {
+	s.Run("should fail job and return error if upload fails on the last attempt", func(t *testing.T) {
 		jobID := "job-123"
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 			Email:     "user@email.com",
 		}
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockStorage.EXPECT().DownloadFile(sts.ctx, videoPath).Return(&domain.DownloadedFile{
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{
 			Path: "/tmp/video.mp4",
-			File: nil,
 		}, nil)
-		sts.mockProcessor.EXPECT().Process(sts.ctx, "/tmp/video.mp4").Return("/tmp/video.zip", "video.zip", nil)
-		sts.mockStorage.EXPECT().UploadFile(sts.ctx, "/tmp/video.zip", "output/video.zip").Return(errors.New("upload error"))
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockErrorPub.EXPECT().Publish(sts.ctx, gomock.Any()).Return(nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).Return(errors.New("upload error"))
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 3)
 
 		sts.Error(err)
 		sts.Contains(err.Error(), "failed to upload processed video to S3")
@@ -214,58 +294,1216 @@ func (sts *jobServiceTestSuite) Test_ProcessJob_Errors() {
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 		}
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockStorage.EXPECT().DownloadFile(sts.ctx, videoPath).Return(&domain.DownloadedFile{
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{
 			Path: "/tmp/video.mp4",
-			File: nil,
 		}, nil)
-		sts.mockProcessor.EXPECT().Process(sts.ctx, "/tmp/video.mp4").Return("/tmp/video.zip", "video.zip", nil)
-		sts.mockStorage.EXPECT().UploadFile(sts.ctx, "/tmp/video.zip", "output/video.zip").Return(nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(errors.New("final status error"))
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(0), errors.New("final status error"))
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.Error(err)
 		sts.Contains(err.Error(), "job completed, but failed to update final status")
 	})
 
-	s.Run("should handle error publishing to error queue", func(t *testing.T) {
+	s.Run("should keep the message when the failure can't be recorded", func(t *testing.T) {
 		jobID := "job-123"
 		videoPath := "s3://upload/video.mp4"
 		job := &domain.VideoJobDTO{
 			ID:        jobID,
-			Status:    "started",
+			Status:    domain.VideoStatusQueued,
 			CreatedAt: "2023-10-01T00:00:00Z",
 			UserID:    "user-123",
 			VideoPath: videoPath,
 			Email:     "pedrinho@gmail.com",
 		}
 
-		event := domain.JobErrorEvent{
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{
+			Path: "/tmp/video.mp4",
+		}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).Return(errors.New("upload error"))
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(0), errors.New("db unavailable"))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 3)
+
+		sts.Error(err)
+		sts.True(domain.IsRetryable(err), "the redelivered message must record the failure")
+		sts.Contains(err.Error(), "failed to upload processed video to S3")
+	})
+
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Retries() {
+	s := sts.T()
+
+	s.Run("should leave job for retry when download fails before the last attempt", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", "attempt 2 failed: download").Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(nil, errors.New("timeout"))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 2)
+
+		sts.Error(err)
+		sts.True(domain.IsRetryable(err))
+	})
+
+	s.Run("should retry when the downloaded video is corrupt", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", gomock.Any()).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(nil, fmt.Errorf("ETag of 'video.mp4' does not match: %w", domain.ErrCorruptDownload))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.ErrorIs(err, domain.ErrCorruptDownload)
+		sts.True(domain.IsRetryable(err))
+	})
+
+	s.Run("should fail job permanently when the video does not exist", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/missing.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(nil, fmt.Errorf("missing: %w", domain.ErrObjectNotFound))
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, failure domain.JobFailure, _ []domain.OutboxMessage) (int64, error) {
+			sts.Equal(domain.FailureVideoNotFound, failure.Code)
+			sts.Equal(domain.StageDownload, failure.Stage)
+			sts.Contains(failure.Message, "failed to download video from S3")
+			return 1, nil
+		})
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.False(domain.IsRetryable(err))
+	})
+
+	s.Run("should retry when the workspace cannot be created", func(t *testing.T) {
+		jobID := "job-123"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: "s3://upload/video.mp4",
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", gomock.Any()).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(nil, errors.New("no space left on device"))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.True(domain.IsRetryable(err))
+	})
+
+	s.Run("should retry when the upload fails while the archive is being written", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", gomock.Any()).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).Return(errors.New("connection reset"))
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ domain.FrameOptions, output io.Writer) (*domain.ProcessedArchive, error) {
+				// Blocks until the upload gives up and closes the stream.
+				if _, err := output.Write([]byte("zip content")); err != nil {
+					return nil, fmt.Errorf("failed to add 'frame_0001.png' to zip: %w", err)
+				}
+				return &domain.ProcessedArchive{FrameCount: 1, SizeBytes: 11}, nil
+			})
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.Contains(err.Error(), "failed to upload processed video to S3")
+		sts.True(domain.IsRetryable(err))
+	})
+
+	s.Run("should retry when job details cannot be fetched", func(t *testing.T) {
+		jobID := "job-123"
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(nil, errors.New("connection reset"))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.True(domain.IsRetryable(err))
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_StateMachine() {
+	s := sts.T()
+
+	s.Run("should discard duplicate message for a completed job", func(t *testing.T) {
+		jobID := "job-123"
+		job := &domain.VideoJobDTO{
+			ID:         jobID,
+			Status:     domain.VideoStatusCompleted,
+			CreatedAt:  "2023-10-01T00:00:00Z",
+			OutputPath: lo.ToPtr("output/video.zip"),
+			UserID:     "user-123",
+			VideoPath:  "s3://upload/video.mp4",
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.NoError(err)
+	})
+
+	s.Run("should discard duplicate message for a job that failed for good", func(t *testing.T) {
+		jobID := "job-123"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusFailed,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: "s3://upload/video.mp4",
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.NoError(err, "the message must be acked without claiming the job again")
+	})
+
+	s.Run("should keep the message when another worker holds the lease", func(t *testing.T) {
+		jobID := "job-123"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusProcessing,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: "s3://upload/video.mp4",
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(domain.ErrJobAlreadyClaimed)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.ErrorIs(err, domain.ErrJobAlreadyClaimed)
+		sts.True(domain.IsRetryable(err), "the message must be redelivered in case the lease owner crashed")
+	})
+
+	s.Run("should detect a lost update when completing the job", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(&domain.VideoJobDTO{ID: jobID, Status: domain.VideoStatusCompleted}, nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.ErrorIs(err, domain.ErrStatusConflict)
+		sts.False(domain.IsRetryable(err))
+	})
+
+	s.Run("should redeliver the message when the job was re-queued while completing it", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(&domain.VideoJobDTO{ID: jobID, Status: domain.VideoStatusQueued}, nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.ErrorIs(err, domain.ErrStatusConflict)
+		sts.True(domain.IsRetryable(err), "no other message is left to claim the re-queued job")
+	})
+
+	s.Run("should not publish a failure the job no longer owns", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(nil, errors.New("process error"))
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(&domain.VideoJobDTO{ID: jobID, Status: domain.VideoStatusCompleted}, nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.False(domain.IsRetryable(err))
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Lease() {
+	s := sts.T()
+
+	s.Run("should renew the lease while the job is running", func(t *testing.T) {
+		jobService := service.NewJobService(
+			sts.mockRepo,
+			sts.mockStorage,
+			sts.mockProcessor,
+			sts.mockEvents,
+			sts.mockNotifier,
+			sts.mockWorkspaces,
+			sts.mockMetrics,
+			service.Config{MaxAttempts: 3, WorkerID: "worker-1", LeaseDuration: 30 * time.Millisecond},
+		)
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", 30*time.Millisecond).Return(nil)
+		sts.mockRepo.EXPECT().ExtendLease(gomock.Any(), jobID, "worker-1", 30*time.Millisecond).Return(nil).MinTimes(1)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).DoAndReturn(func(context.Context, string, domain.FrameOptions, io.Writer) (*domain.ProcessedArchive, error) {
+			time.Sleep(50 * time.Millisecond)
+			return &domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil
+		})
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		err := jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.NoError(err)
+	})
+
+	s.Run("should discard message when the job finished before it could be claimed", func(t *testing.T) {
+		jobID := "job-123"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusProcessing,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: "s3://upload/video.mp4",
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(fmt.Errorf("job is 'completed': %w", domain.ErrInvalidTransition))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.NoError(err)
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Redelivery() {
+	s := sts.T()
+
+	s.Run("should let another worker claim the job redelivered after a retryable attempt", func(t *testing.T) {
+		repo := repository.NewMemoryVideoJobRepository(repository.NewMemoryOutboxRepository())
+		job := repo.AddJob(domain.VideoJob{UserID: "user-123", VideoPath: "s3://upload/video.mp4"}, "user@example.com")
+		newWorker := func(workerID string) *service.JobService {
+			return service.NewJobService(
+				repo,
+				sts.mockStorage,
+				sts.mockProcessor,
+				sts.mockEvents,
+				sts.mockNotifier,
+				sts.mockWorkspaces,
+				sts.mockMetrics,
+				service.Config{MaxAttempts: 3, WorkerID: workerID, LeaseDuration: time.Hour},
+			)
+		}
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil).Times(2)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil).Times(2)
+		gomock.InOrder(
+			sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(nil, errors.New("connection reset")),
+			sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil),
+		)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/"+job.ID+".zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/"+job.ID+".zip").Return(testDownload, nil)
+
+		err := newWorker("worker-1").ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+		sts.True(domain.IsRetryable(err))
+		released, _ := repo.Job(job.ID)
+		sts.Equal(domain.VideoStatusQueued, released.Status, "the failed attempt must give up its lease")
+		sts.Nil(released.LeaseOwner)
+
+		err = newWorker("worker-2").ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 2)
+
+		sts.NoError(err)
+		completed, _ := repo.Job(job.ID)
+		sts.Equal(domain.VideoStatusCompleted, completed.Status)
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Cancelled() {
+	s := sts.T()
+
+	s.Run("should not fail job when processing is interrupted by shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", "processing interrupted").Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).DoAndReturn(func(ctx context.Context, _ string, _ *domain.Workspace) (*domain.DownloadedFile, error) {
+			cancel()
+			return nil, ctx.Err()
+		})
+
+		err := sts.jobService.ProcessJob(ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.ErrorIs(err, context.Canceled)
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_FrameOptions() {
+	s := sts.T()
+
+	s.Run("should pass the job's frame options to the processor", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		event := domain.JobMessageEvent{
 			JobID: jobID,
+			Options: &domain.FrameOptions{
+				IntervalSeconds: 5,
+				Format:          domain.ImageFormatJPG,
+				MaxWidth:        640,
+				StartSeconds:    10,
+				EndSeconds:      70,
+			},
 		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", domain.FrameOptions{
+			IntervalSeconds: 5,
+			Format:          domain.ImageFormatJPG,
+			Quality:         85,
+			MaxWidth:        640,
+			StartSeconds:    10,
+			EndSeconds:      70,
+		}, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(1), nil)
 
-		sts.mockRepo.EXPECT().GetJobByID(sts.ctx, jobID).Return(job, nil)
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockStorage.EXPECT().DownloadFile(sts.ctx, videoPath).Return(&domain.DownloadedFile{
-			Path: "/tmp/video.mp4",
-			File: nil,
+		err := sts.jobService.ProcessJob(sts.ctx, event, 1)
+
+		sts.NoError(err)
+	})
+
+	invalid := map[string]domain.FrameOptions{
+		"fps and interval":       {FPS: 2, IntervalSeconds: 5},
+		"fps above limit":        {FPS: 120},
+		"interval below limit":   {IntervalSeconds: 0.001},
+		"unsupported format":     {Format: "gif"},
+		"quality above limit":    {Format: domain.ImageFormatJPG, Quality: 150},
+		"negative size":          {MaxWidth: -1},
+		"end before start":       {StartSeconds: 30, EndSeconds: 10},
+		"negative start":         {StartSeconds: -1},
+		"negative interval":      {IntervalSeconds: -5},
+		"max height above limit": {MaxHeight: 10000},
+		"negative fps":           {FPS: -1},
+	}
+	for name, opts := range invalid {
+		s.Run("should fail job permanently with invalid options: "+name, func(t *testing.T) {
+			jobID := "job-123"
+			job := &domain.VideoJobDTO{
+				ID:        jobID,
+				Status:    domain.VideoStatusQueued,
+				CreatedAt: "2023-10-01T00:00:00Z",
+				UserID:    "user-123",
+				VideoPath: "s3://upload/video.mp4",
+			}
+			sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+			sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+			sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+			err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID, Options: &opts}, 1)
+
+			sts.ErrorIs(err, domain.ErrInvalidFrameOptions)
+			sts.False(domain.IsRetryable(err))
+		})
+	}
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Probe() {
+	s := sts.T()
+
+	s.Run("should fail job permanently when the input is not a video", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/foto.jpg"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/foto.jpg"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/foto.jpg").Return(nil, fmt.Errorf("%w: 'image2' is a still image, not a video", domain.ErrInvalidVideo))
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, failure domain.JobFailure, _ []domain.OutboxMessage) (int64, error) {
+			sts.Equal(domain.FailureInvalidVideo, failure.Code)
+			sts.Equal(domain.StageProbe, failure.Stage)
+			sts.Contains(failure.Message, "still image, not a video")
+			return 1, nil
+		})
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.ErrorIs(err, domain.ErrInvalidVideo)
+		sts.False(domain.IsRetryable(err))
+	})
+
+	s.Run("should store metadata and fail job permanently when the video is over the limits", func(t *testing.T) {
+		jobService := service.NewJobService(
+			sts.mockRepo,
+			sts.mockStorage,
+			sts.mockProcessor,
+			sts.mockEvents,
+			sts.mockNotifier,
+			sts.mockWorkspaces,
+			sts.mockMetrics,
+			service.Config{
+				MaxAttempts:   3,
+				WorkerID:      "worker-1",
+				LeaseDuration: time.Minute,
+				Limits:        service.VideoLimits{MaxDuration: 30 * time.Second},
+			},
+		)
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		err := jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.ErrorIs(err, domain.ErrVideoLimitExceeded)
+		sts.Contains(err.Error(), "duration 1m0s is above the 30s limit")
+		sts.False(domain.IsRetryable(err))
+	})
+
+	s.Run("should retry when ffprobe cannot run", func(t *testing.T) {
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", gomock.Any()).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(nil, errors.New("ffprobe execution error: signal: killed"))
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		sts.True(domain.IsRetryable(err))
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Metrics() {
+	s := sts.T()
+
+	newJobService := func(metrics *mocks.MockMetricsRecorder) *service.JobService {
+		return service.NewJobService(
+			sts.mockRepo,
+			sts.mockStorage,
+			sts.mockProcessor,
+			sts.mockEvents,
+			sts.mockNotifier,
+			sts.mockWorkspaces,
+			metrics,
+			service.Config{MaxAttempts: 3, WorkerID: "worker-1", LeaseDuration: time.Minute},
+		)
+	}
+
+	s.Run("should record stage durations and bytes of a successful job", func(t *testing.T) {
+		metrics := mocks.NewMockMetricsRecorder(gomock.NewController(t))
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4", SizeBytes: 10 << 20}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{
+			FrameCount:      10,
+			SizeBytes:       1024,
+			ExtractDuration: 3 * time.Second,
+			ZipDuration:     time.Second,
 		}, nil)
-		sts.mockProcessor.EXPECT().Process(sts.ctx, "/tmp/video.mp4").Return("/tmp/video.zip", "video.zip", nil)
-		sts.mockStorage.EXPECT().UploadFile(sts.ctx, "/tmp/video.zip", "output/video.zip").Return(errors.New("upload error"))
-		sts.mockRepo.EXPECT().UpdateJobStatus(sts.ctx, gomock.Any()).Return(nil)
-		sts.mockErrorPub.EXPECT().Publish(sts.ctx, event).Return(errors.New("publish error"))
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		metrics.EXPECT().ObserveStage("download", gomock.Any())
+		metrics.EXPECT().AddBytesDownloaded(int64(10 << 20))
+		metrics.EXPECT().ObserveStage("probe", gomock.Any())
+		metrics.EXPECT().ObserveStage("ffmpeg", 3*time.Second)
+		metrics.EXPECT().ObserveStage("zip", time.Second)
+		metrics.EXPECT().ObserveStage("upload", gomock.Any())
+		metrics.EXPECT().AddBytesUploaded(int64(1024))
+		metrics.EXPECT().JobSucceeded(gomock.Any())
 
-		err := sts.jobService.ProcessJob(sts.ctx, jobID)
+		err := newJobService(metrics).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.NoError(err)
+	})
+
+	s.Run("should record the reason of a permanent failure", func(t *testing.T) {
+		metrics := mocks.NewMockMetricsRecorder(gomock.NewController(t))
+		jobID := "job-123"
+		videoPath := "s3://upload/missing.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(nil, domain.ErrObjectNotFound)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		metrics.EXPECT().JobFailed(domain.FailureVideoNotFound, false)
+
+		err := newJobService(metrics).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
 
 		sts.Error(err)
-		sts.Contains(err.Error(), "failed to upload processed video to S3")
 	})
 
+	s.Run("should record a failed upload that will be retried", func(t *testing.T) {
+		metrics := mocks.NewMockMetricsRecorder(gomock.NewController(t))
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), jobID, "worker-1", gomock.Any()).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).Return(errors.New("upload error"))
+
+		metrics.EXPECT().ObserveStage(gomock.Any(), gomock.Any()).Times(2)
+		metrics.EXPECT().AddBytesDownloaded(gomock.Any())
+		metrics.EXPECT().JobFailed(domain.FailureUpload, true)
+
+		err := newJobService(metrics).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.True(domain.IsRetryable(err))
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Tracing() {
+	s := sts.T()
+
+	// The service's tracer delegates to the first provider installed, so the
+	// recorder is shared by the subtests and reset before each of them.
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	spansByName := func() map[string]sdktrace.ReadOnlySpan {
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		return spans
+	}
+
+	s.Run("should trace every step of a job under a single span", func(t *testing.T) {
+		recorder.Reset()
+		jobID := "job-123"
+		videoPath := "s3://upload/video.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), jobID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.NoError(err)
+		spans := spansByName()
+		root, ok := spans["ProcessJob"]
+		sts.Require().True(ok, "expected a ProcessJob span")
+		for _, name := range []string{"db.get_job", "db.claim_job", "s3.download", "ffprobe", "db.save_metadata", "ffmpeg", "s3.upload", "db.mark_completed"} {
+			span, ok := spans[name]
+			if sts.True(ok, "expected a %s span", name) {
+				sts.Equal(root.SpanContext().SpanID(), span.Parent().SpanID(), "expected %s to be a child of ProcessJob", name)
+				sts.Equal(codes.Unset, span.Status().Code)
+			}
+		}
+	})
+
+	s.Run("should mark the failed step and the job span as errors", func(t *testing.T) {
+		recorder.Reset()
+		jobID := "job-123"
+		videoPath := "s3://upload/missing.mp4"
+		job := &domain.VideoJobDTO{
+			ID:        jobID,
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			VideoPath: videoPath,
+		}
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), videoPath, testWorkspace).Return(nil, domain.ErrObjectNotFound)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		err := sts.jobService.ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: jobID}, 1)
+
+		sts.Error(err)
+		spans := spansByName()
+		sts.Equal(codes.Error, spans["s3.download"].Status().Code)
+		sts.Equal(codes.Error, spans["ProcessJob"].Status().Code)
+		sts.Contains(spans, "db.mark_failed")
+		sts.NotContains(spans, "sqs.publish_error", "expected the error event to go through the outbox")
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Logging() {
+	s := sts.T()
+
+	s.Run("should add the job owner to the lines logged for the job", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := logger.WithContext(sts.ctx, logger.New(&buf, slog.LevelInfo).With(logger.JobIDKey, "job-123"))
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), "job-123").Return(&domain.VideoJobDTO{
+			ID:     "job-123",
+			Status: domain.VideoStatusCompleted,
+			UserID: "user-123",
+		}, nil)
+
+		err := sts.jobService.ProcessJob(ctx, domain.JobMessageEvent{JobID: "job-123"}, 1)
+
+		sts.NoError(err)
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		sts.Require().Len(lines, 2)
+		var record map[string]any
+		sts.Require().NoError(json.Unmarshal(lines[1], &record))
+		sts.Equal("job-123", record[logger.JobIDKey])
+		sts.Equal("user-123", record[logger.UserIDKey])
+		sts.Equal(string(domain.VideoStatusCompleted), record["status"])
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Notifications() {
+	s := sts.T()
+
+	newJobService := func(notifier *mocks.MockNotifier) *service.JobService {
+		return service.NewJobService(
+			sts.mockRepo,
+			sts.mockStorage,
+			sts.mockProcessor,
+			sts.mockEvents,
+			notifier,
+			sts.mockWorkspaces,
+			sts.mockMetrics,
+			service.Config{MaxAttempts: 3, WorkerID: "worker-1", LeaseDuration: time.Minute},
+		)
+	}
+	newJob := func(videoPath string) *domain.VideoJobDTO {
+		return &domain.VideoJobDTO{
+			ID:        "job-123",
+			Status:    domain.VideoStatusQueued,
+			CreatedAt: "2023-10-01T00:00:00Z",
+			UserID:    "user-123",
+			Email:     "user@example.com",
+			VideoPath: videoPath,
+		}
+	}
+	expectSuccess := func(job *domain.VideoJobDTO) {
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), job.ID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), job.ID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	}
+
+	s.Run("should send the download link when the job completes", func(t *testing.T) {
+		notifier := mocks.NewMockNotifier(gomock.NewController(t))
+		job := newJob("s3://upload/video.mp4")
+		expectSuccess(job)
+		notifier.EXPECT().JobCompleted(gomock.Any(), job, testDownload).Return(nil)
+
+		err := newJobService(notifier).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.NoError(err)
+	})
+
+	s.Run("should complete the job without a link when presigning fails", func(t *testing.T) {
+		notifier := mocks.NewMockNotifier(gomock.NewController(t))
+		job := newJob("s3://upload/video.mp4")
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), job.ID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{FrameCount: 10, SizeBytes: 1024}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(nil, errors.New("no credentials"))
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), job.ID, "worker-1", "output/job-123.zip", domain.JobStats{FrameCount: 10, ArchiveSize: 1024}, gomock.Any()).Return(int64(1), nil)
+		notifier.EXPECT().JobCompleted(gomock.Any(), job, nil).Return(nil)
+
+		err := newJobService(notifier).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.NoError(err)
+	})
+
+	s.Run("should not fail the job when the notification fails", func(t *testing.T) {
+		notifier := mocks.NewMockNotifier(gomock.NewController(t))
+		job := newJob("s3://upload/video.mp4")
+		expectSuccess(job)
+		notifier.EXPECT().JobCompleted(gomock.Any(), job, gomock.Any()).Return(errors.New("smtp unavailable"))
+
+		err := newJobService(notifier).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.NoError(err)
+	})
+
+	s.Run("should send the reason when the job fails", func(t *testing.T) {
+		notifier := mocks.NewMockNotifier(gomock.NewController(t))
+		job := newJob("s3://upload/missing.mp4")
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(nil, domain.ErrObjectNotFound)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), job.ID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(1), nil)
+		notifier.EXPECT().JobFailed(gomock.Any(), job, domain.FailureVideoNotFound).Return(nil)
+
+		err := newJobService(notifier).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.Error(err)
+	})
+
+	s.Run("should not notify while the job will be retried", func(t *testing.T) {
+		notifier := mocks.NewMockNotifier(gomock.NewController(t))
+		job := newJob("s3://upload/video.mp4")
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockRepo.EXPECT().ReleaseJob(gomock.Any(), job.ID, "worker-1", gomock.Any()).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(nil, errors.New("connection reset"))
+
+		err := newJobService(notifier).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.True(domain.IsRetryable(err))
+	})
+
+	s.Run("should not notify when another worker changed the job", func(t *testing.T) {
+		notifier := mocks.NewMockNotifier(gomock.NewController(t))
+		job := newJob("s3://upload/missing.mp4")
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(nil, domain.ErrObjectNotFound)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), job.ID, "worker-1", gomock.Any(), gomock.Any()).Return(int64(0), nil)
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(&domain.VideoJobDTO{ID: job.ID, Status: domain.VideoStatusCompleted}, nil)
+
+		err := newJobService(notifier).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.Error(err)
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_Events() {
+	s := sts.T()
+
+	newJobService := func(events *mocks.MockEventPublisher) *service.JobService {
+		return service.NewJobService(
+			sts.mockRepo,
+			sts.mockStorage,
+			sts.mockProcessor,
+			events,
+			sts.mockNotifier,
+			sts.mockWorkspaces,
+			sts.mockMetrics,
+			service.Config{MaxAttempts: 3, WorkerID: "worker-1", LeaseDuration: time.Minute},
+		)
+	}
+	job := &domain.VideoJobDTO{
+		ID:        "job-123",
+		Status:    domain.VideoStatusQueued,
+		CreatedAt: "2023-10-01T00:00:00Z",
+		UserID:    "user-123",
+		VideoPath: "s3://upload/video.mp4",
+	}
+	recordEvents := func(events *mocks.MockEventPublisher, err error) *[]domain.JobEvent {
+		var published []domain.JobEvent
+		events.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, event domain.JobEvent) error {
+				published = append(published, event)
+				return err
+			}).
+			AnyTimes()
+		return &published
+	}
+	// outboxEvent decodes the lifecycle event written to the outbox.
+	outboxEvent := func(outbox []domain.OutboxMessage) domain.JobEvent {
+		for _, message := range outbox {
+			if message.Destination == domain.OutboxEvents {
+				event, err := domain.DecodeJobEvent(domain.JobEventType(message.EventType), []byte(message.Payload))
+				sts.Require().NoError(err)
+				return event
+			}
+		}
+		sts.FailNow("expected a lifecycle event in the outbox")
+		return nil
+	}
+	expectSuccess := func() *[]domain.OutboxMessage {
+		var outbox []domain.OutboxMessage
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), job.ID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(&domain.ProcessedArchive{
+			FrameCount:      10,
+			SizeBytes:       1024,
+			ExtractDuration: 3 * time.Second,
+			ZipDuration:     time.Second,
+		}, nil)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		sts.mockStorage.EXPECT().PresignDownload(gomock.Any(), "output/job-123.zip").Return(testDownload, nil)
+		sts.mockRepo.EXPECT().MarkCompleted(gomock.Any(), job.ID, "worker-1", "output/job-123.zip", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _, _ string, _ domain.JobStats, messages []domain.OutboxMessage) (int64, error) {
+				outbox = messages
+				return 1, nil
+			})
+		return &outbox
+	}
+
+	s.Run("should publish the lifecycle of a completed job", func(t *testing.T) {
+		events := mocks.NewMockEventPublisher(gomock.NewController(t))
+		published := recordEvents(events, nil)
+		outbox := expectSuccess()
+
+		err := newJobService(events).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 2)
+
+		sts.Require().NoError(err)
+		sts.Require().Len(*published, 4, "expected the completed event to go through the outbox")
+		started, ok := (*published)[0].(domain.JobStartedEvent)
+		sts.Require().True(ok, "expected a started event, got %T", (*published)[0])
+		sts.Equal(domain.JobEventStarted, started.Type)
+		sts.Equal(2, started.Attempt)
+		var stages []domain.JobStage
+		for _, event := range (*published)[1:4] {
+			progress, ok := event.(domain.JobProgressEvent)
+			sts.Require().True(ok, "expected a progress event, got %T", event)
+			stages = append(stages, progress.Stage)
+		}
+		sts.Equal([]domain.JobStage{domain.StageDownload, domain.StageProbe, domain.StageExtract}, stages)
+
+		sts.Require().Len(*outbox, 1)
+		sts.Equal("job-123", (*outbox)[0].JobID)
+		completed, ok := outboxEvent(*outbox).(domain.JobCompletedEvent)
+		sts.Require().True(ok, "expected a completed event in the outbox")
+		sts.Equal(domain.JobEventCompleted, completed.Type)
+		sts.Equal("job-123", completed.JobID)
+		sts.Equal("user-123", completed.UserID)
+		sts.Equal("worker-1", completed.WorkerID)
+		sts.False(completed.OccurredAt.IsZero())
+		sts.Equal("output/job-123.zip", completed.OutputPath)
+		sts.Equal(testDownload.URL, completed.DownloadURL)
+		sts.Equal(testDownload.ExpiresAt, *completed.DownloadURLExpiresAt)
+		sts.Equal(10, completed.FrameCount)
+		sts.Equal(int64(1024), completed.ArchiveSizeBytes)
+		sts.Equal(int64(3000), completed.ExtractDurationMs)
+		sts.Equal(int64(1000), completed.ZipDurationMs)
+	})
+
+	s.Run("should write a failed event to the outbox when the job fails for good", func(t *testing.T) {
+		events := mocks.NewMockEventPublisher(gomock.NewController(t))
+		recordEvents(events, nil)
+		var outbox []domain.OutboxMessage
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), job.ID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), job.ID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(job.ID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(nil, domain.ErrObjectNotFound)
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), job.ID, "worker-1", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, _ domain.JobFailure, messages []domain.OutboxMessage) (int64, error) {
+				outbox = messages
+				return 1, nil
+			})
+
+		err := newJobService(events).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.Error(err)
+		failed, ok := outboxEvent(outbox).(domain.JobFailedEvent)
+		sts.Require().True(ok, "expected a failed event in the outbox")
+		sts.Equal(domain.JobEventFailed, failed.Type)
+		sts.Equal(domain.FailureVideoNotFound, failed.Reason)
+		sts.Equal(1, failed.Attempt)
+	})
+
+	s.Run("should not fail the job when publishing fails", func(t *testing.T) {
+		events := mocks.NewMockEventPublisher(gomock.NewController(t))
+		recordEvents(events, errors.New("queue unavailable"))
+		expectSuccess()
+
+		err := newJobService(events).ProcessJob(sts.ctx, domain.JobMessageEvent{JobID: job.ID}, 1)
+
+		sts.NoError(err)
+	})
+}
+
+func (sts *jobServiceTestSuite) Test_ProcessJob_ErrorEvent() {
+	s := sts.T()
+
+	s.Run("should describe the failure in the error event and on the job", func(t *testing.T) {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		recorder := tracetest.NewSpanRecorder()
+		ctx, root := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(sts.ctx, "consume")
+		defer root.End()
+		jobID := "job-123"
+		job := &domain.VideoJobDTO{ID: jobID, Status: domain.VideoStatusQueued, UserID: "user-123", VideoPath: "s3://upload/video.mp4"}
+		stderr := strings.Repeat("frame decode noise\n", 500) + "Error while decoding stream #0:0: Invalid data found when processing input"
+		zipErr := domain.NewStageError(domain.StageZip, stderr, errors.New("no frames extracted"))
+
+		sts.mockRepo.EXPECT().GetJobByID(gomock.Any(), jobID).Return(job, nil)
+		sts.mockRepo.EXPECT().ClaimJob(gomock.Any(), jobID, "worker-1", time.Minute).Return(nil)
+		sts.mockWorkspaces.EXPECT().Create(jobID).Return(testWorkspace, nil)
+		sts.mockWorkspaces.EXPECT().Remove(testWorkspace).Return(nil)
+		sts.mockStorage.EXPECT().DownloadFile(gomock.Any(), job.VideoPath, testWorkspace).Return(&domain.DownloadedFile{Path: "/tmp/video.mp4"}, nil)
+		sts.mockProcessor.EXPECT().ProbeVideo(gomock.Any(), "/tmp/video.mp4").Return(validMetadata, nil)
+		sts.mockRepo.EXPECT().SaveVideoMetadata(gomock.Any(), jobID, "worker-1", *validMetadata).Return(nil)
+		sts.mockProcessor.EXPECT().Process(gomock.Any(), "/tmp/video.mp4", defaultFrameOptions, gomock.Any()).Return(nil, zipErr)
+		sts.mockStorage.EXPECT().UploadStream(gomock.Any(), "output/job-123.zip", gomock.Any()).DoAndReturn(drainUpload)
+		var failure domain.JobFailure
+		var outbox []domain.OutboxMessage
+		sts.mockRepo.EXPECT().MarkFailed(gomock.Any(), jobID, "worker-1", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, f domain.JobFailure, messages []domain.OutboxMessage) (int64, error) {
+				failure = f
+				outbox = messages
+				return 1, nil
+			})
+
+		err := sts.jobService.ProcessJob(ctx, domain.JobMessageEvent{JobID: jobID}, 2)
+
+		sts.Require().Error(err)
+		sts.Require().Len(outbox, 2)
+		message := outbox[0]
+		sts.Equal(domain.OutboxErrorQueue, message.Destination)
+		sts.Equal(domain.JobErrorEventType, message.EventType)
+		sts.Contains(message.Headers["traceparent"], root.SpanContext().TraceID().String(), "expected the trace context to be kept for the relay")
+		var event domain.JobErrorEvent
+		sts.Require().NoError(json.Unmarshal([]byte(message.Payload), &event))
+		sts.Equal(jobID, event.JobID)
+		sts.Equal(domain.FailureProcessing, event.Code)
+		sts.Equal(domain.StageZip, event.Stage, "expected the processor's stage to win")
+		sts.Contains(event.Message, "no frames extracted")
+		sts.LessOrEqual(len(event.Stderr), 2048+len("..."))
+		sts.True(strings.HasPrefix(event.Stderr, "..."))
+		sts.True(strings.HasSuffix(event.Stderr, "Invalid data found when processing input"))
+		sts.Equal(2, event.Attempt)
+		sts.Equal("worker-1", event.WorkerID)
+		sts.False(event.OccurredAt.IsZero())
+		sts.Equal(domain.OutboxEvents, outbox[1].Destination)
+		sts.Equal(string(domain.JobEventFailed), outbox[1].EventType)
+		sts.Equal(domain.JobFailure{Code: event.Code, Stage: event.Stage, Message: event.Message, Stderr: event.Stderr}, failure)
+	})
 }
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+)
+
+// LeaseReaper periodically puts jobs whose lease expired back to queued, so
+// jobs held by a crashed worker can be claimed again.
+type LeaseReaper struct {
+	repo     ports.VideoJobRepository
+	workerID string
+	interval time.Duration
+}
+
+func NewLeaseReaper(repo ports.VideoJobRepository, workerID string, interval time.Duration) *LeaseReaper {
+	return &LeaseReaper{
+		repo:     repo,
+		workerID: workerID,
+		interval: interval,
+	}
+}
+
+// Start runs the reaper until ctx is cancelled.
+func (r *LeaseReaper) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+func (r *LeaseReaper) reap(ctx context.Context) {
+	released, err := r.repo.ReleaseExpiredLeases(ctx, r.workerID)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.FromContext(ctx).Error("Failed to release expired job leases.", "error", err)
+		}
+		return
+	}
+	if released > 0 {
+		logger.FromContext(ctx).Info("Released jobs with expired leases.", "count", released)
+	}
+}
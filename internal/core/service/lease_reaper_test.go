@@ -0,0 +1,40 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLeaseReaper_Start(t *testing.T) {
+	t.Run("should release expired leases until the context is cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockVideoJobRepository(ctrl)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		repo.EXPECT().ReleaseExpiredLeases(gomock.Any(), "worker-1").Return(int64(2), nil).Times(1)
+		repo.EXPECT().ReleaseExpiredLeases(gomock.Any(), "worker-1").DoAndReturn(func(context.Context, string) (int64, error) {
+			cancel()
+			return 0, assert.AnError
+		}).Times(1)
+
+		reaper := service.NewLeaseReaper(repo, "worker-1", 10*time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			reaper.Start(ctx)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("reaper did not stop after context cancellation")
+		}
+	})
+}
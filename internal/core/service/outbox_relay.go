@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// outboxClaimLease is how long a claimed outbox message stays hidden from
+// other relays. A relay that crashes mid-batch leaves its messages due again
+// once it runs out.
+const outboxClaimLease = time.Minute
+
+// outboxFirstBackoff is how long a message waits after its first failed
+// publish. Each further failure doubles it, up to OutboxRelayConfig.MaxBackoff.
+const outboxFirstBackoff = time.Second
+
+type OutboxRelayConfig struct {
+	// Interval is how often the outbox is checked for due messages.
+	Interval time.Duration
+	// BatchSize is how many messages are claimed at once.
+	BatchSize int
+	// MaxBackoff caps how long a message waits between failed publishes.
+	MaxBackoff time.Duration
+}
+
+// OutboxRelay publishes the messages written to the outbox along with job
+// status changes, retrying failed publishes with exponential backoff until
+// they go through.
+type OutboxRelay struct {
+	repo     ports.OutboxRepository
+	errorPub ports.QueueAdapter
+	events   ports.EventPublisher
+	cfg      OutboxRelayConfig
+}
+
+func NewOutboxRelay(repo ports.OutboxRepository, errorPub ports.QueueAdapter, events ports.EventPublisher, cfg OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		repo:     repo,
+		errorPub: errorPub,
+		events:   events,
+		cfg:      cfg,
+	}
+}
+
+// Start runs the relay until ctx is cancelled.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+// relay publishes due messages until the outbox has no full batch left.
+func (r *OutboxRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := r.repo.ClaimDue(ctx, r.cfg.BatchSize, outboxClaimLease)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.FromContext(ctx).Error("Failed to claim outbox messages.", "error", err)
+			}
+			return
+		}
+		for _, message := range messages {
+			r.send(ctx, message)
+		}
+		if len(messages) == 0 || len(messages) < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) send(ctx context.Context, message domain.OutboxMessage) {
+	log := logger.FromContext(ctx).With(logger.JobIDKey, message.JobID, "outbox_id", message.ID, "event_type", message.EventType)
+	msgCtx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(message.Headers))
+	spanCtx, span := tracer.Start(msgCtx, "outbox.publish", trace.WithAttributes(
+		attribute.String("event.type", message.EventType),
+		attribute.String("outbox.destination", string(message.Destination)),
+		attribute.Int("outbox.attempts", message.Attempts),
+	))
+	err := r.publish(spanCtx, message)
+	tracing.End(span, err)
+
+	if err != nil {
+		retryAt := time.Now().Add(r.backoff(message.Attempts))
+		log.Warn("Failed to publish outbox message, it will be retried.", "attempts", message.Attempts+1, "retry_at", retryAt, "error", err)
+		if err := r.repo.MarkFailed(ctx, message.ID, err.Error(), retryAt); err != nil {
+			log.Error("Failed to record failed publish of outbox message.", "error", err)
+		}
+		return
+	}
+	// A message that was published but not marked is published again once its
+	// claim expires, so consumers must tolerate duplicates.
+	if err := r.repo.MarkSent(ctx, message.ID); err != nil {
+		log.Error("Failed to mark outbox message as sent.", "error", err)
+	}
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, message domain.OutboxMessage) error {
+	switch message.Destination {
+	case domain.OutboxErrorQueue:
+		var event domain.JobErrorEvent
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			return fmt.Errorf("failed to decode error event: %w", err)
+		}
+		return r.errorPub.Publish(ctx, event)
+	case domain.OutboxEvents:
+		event, err := domain.DecodeJobEvent(domain.JobEventType(message.EventType), []byte(message.Payload))
+		if err != nil {
+			return err
+		}
+		return r.events.Publish(ctx, event)
+	default:
+		return fmt.Errorf("unknown outbox destination '%s'", message.Destination)
+	}
+}
+
+// backoff is how long to wait before publishing again a message that already
+// failed attempts times, not counting the failure being recorded.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	backoff := outboxFirstBackoff
+	for i := 0; i < attempts && backoff < r.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, r.cfg.MaxBackoff)
+}
@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports/mocks"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOutboxRelay_Start(t *testing.T) {
+	cfg := service.OutboxRelayConfig{Interval: 10 * time.Millisecond, BatchSize: 2, MaxBackoff: time.Minute}
+	errorMessage := domain.OutboxMessage{
+		ID:          "outbox-1",
+		JobID:       "job-123",
+		Destination: domain.OutboxErrorQueue,
+		EventType:   domain.JobErrorEventType,
+		Payload:     `{"job_id":"job-123","code":"processing","stage":"extract","message":"ffmpeg execution error","attempt":3}`,
+	}
+	completedMessage := domain.OutboxMessage{
+		ID:          "outbox-2",
+		JobID:       "job-123",
+		Destination: domain.OutboxEvents,
+		EventType:   string(domain.JobEventCompleted),
+		Payload:     `{"type":"job.completed","job_id":"job-123","output_path":"output/job-123.zip","frame_count":10}`,
+	}
+
+	// run starts the relay and waits for it to return once ctx is cancelled,
+	// failing the test if it keeps running.
+	run := func(t *testing.T, relay *service.OutboxRelay, ctx context.Context) {
+		done := make(chan struct{})
+		go func() {
+			relay.Start(ctx)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("relay did not stop after context cancellation")
+		}
+	}
+
+	t.Run("should publish due messages to their destination and mark them sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockOutboxRepository(ctrl)
+		errorPub := mocks.NewMockQueueAdapter(ctrl)
+		events := mocks.NewMockEventPublisher(ctrl)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// A full batch makes the relay claim again right away.
+		repo.EXPECT().ClaimDue(gomock.Any(), 2, time.Minute).Return([]domain.OutboxMessage{errorMessage, completedMessage}, nil)
+		repo.EXPECT().ClaimDue(gomock.Any(), 2, time.Minute).DoAndReturn(func(context.Context, int, time.Duration) ([]domain.OutboxMessage, error) {
+			cancel()
+			return nil, nil
+		})
+		errorPub.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event domain.JobErrorEvent) error {
+			assert.Equal(t, "job-123", event.JobID)
+			assert.Equal(t, domain.FailureProcessing, event.Code)
+			assert.Equal(t, 3, event.Attempt)
+			return nil
+		})
+		events.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event domain.JobEvent) error {
+			completed, ok := event.(domain.JobCompletedEvent)
+			require.True(t, ok, "expected a completed event, got %T", event)
+			assert.Equal(t, "output/job-123.zip", completed.OutputPath)
+			assert.Equal(t, 10, completed.FrameCount)
+			return nil
+		})
+		repo.EXPECT().MarkSent(gomock.Any(), "outbox-1").Return(nil)
+		repo.EXPECT().MarkSent(gomock.Any(), "outbox-2").Return(nil)
+
+		run(t, service.NewOutboxRelay(repo, errorPub, events, cfg), ctx)
+	})
+
+	t.Run("should back off exponentially when publishing fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockOutboxRepository(ctrl)
+		errorPub := mocks.NewMockQueueAdapter(ctrl)
+		events := mocks.NewMockEventPublisher(ctrl)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		failing := completedMessage
+		failing.Attempts = 3
+		repo.EXPECT().ClaimDue(gomock.Any(), 2, time.Minute).DoAndReturn(func(context.Context, int, time.Duration) ([]domain.OutboxMessage, error) {
+			cancel()
+			return []domain.OutboxMessage{failing}, nil
+		})
+		events.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("queue unavailable"))
+		before := time.Now()
+		repo.EXPECT().MarkFailed(gomock.Any(), "outbox-2", "queue unavailable", gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, retryAt time.Time) error {
+			assert.WithinDuration(t, before.Add(8*time.Second), retryAt, time.Second)
+			return nil
+		})
+
+		run(t, service.NewOutboxRelay(repo, errorPub, events, cfg), ctx)
+	})
+
+	t.Run("should not wait longer than the max backoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mocks.NewMockOutboxRepository(ctrl)
+		errorPub := mocks.NewMockQueueAdapter(ctrl)
+		events := mocks.NewMockEventPublisher(ctrl)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		failing := errorMessage
+		failing.Attempts = 40
+		repo.EXPECT().ClaimDue(gomock.Any(), 2, time.Minute).DoAndReturn(func(context.Context, int, time.Duration) ([]domain.OutboxMessage, error) {
+			cancel()
+			return []domain.OutboxMessage{failing}, nil
+		})
+		errorPub.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("queue unavailable"))
+		before := time.Now()
+		repo.EXPECT().MarkFailed(gomock.Any(), "outbox-1", "queue unavailable", gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, retryAt time.Time) error {
+			assert.WithinDuration(t, before.Add(time.Minute), retryAt, time.Second)
+			return nil
+		})
+
+		run(t, service.NewOutboxRelay(repo, errorPub, events, cfg), ctx)
+	})
+}
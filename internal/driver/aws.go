@@ -0,0 +1,107 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input/httpserver"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/events"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/queue"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/repository"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/aws"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/nats"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/postgres"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/clients/rabbitmq"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/config"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// NewAWS keeps jobs in Postgres, files in S3 and messages in the configured
+// queue backend, connecting to them with config.Vars.
+func NewAWS(ctx context.Context) (*Adapters, error) {
+	cfg := config.Vars
+
+	db, err := postgres.NewPostgresClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+
+	awsCfg, err := aws.NewAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize AWS configuration: %w", err)
+	}
+
+	s3Client := s3.NewFromConfig(awsCfg, func(o *s3.Options) { o.UsePathStyle = true })
+	sqsClient := sqs.NewFromConfig(awsCfg)
+
+	videoRepository := repository.NewVideoJobRepository(db)
+	storageAdapter := storage.NewS3Adapter(s3Client, s3.NewPresignClient(s3Client), cfg.S3Bucket, storage.S3Options{
+		PartSize:      cfg.S3UploadPartSize,
+		Concurrency:   cfg.S3UploadConcurrency,
+		PresignExpiry: cfg.S3PresignExpiry,
+	})
+	a := &Adapters{
+		Repository: videoRepository,
+		Outbox:     repository.NewOutboxRepository(db),
+		Storage:    storageAdapter,
+		Readiness: map[string]httpserver.Check{
+			"postgres": videoRepository.Ping,
+			"s3":       storageAdapter.Ping,
+		},
+		Close: func() {},
+	}
+
+	switch cfg.QueueBackend {
+	case "rabbitmq":
+		conn, channel, err := rabbitmq.NewRabbitMQClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		}
+		a.Close = func() { _ = conn.Close() }
+		// Deliveries are bound to the connection, so the worker exits when the
+		// broker drops it and is restarted rather than reconnecting.
+		failed := make(chan error, 1)
+		go func() {
+			if err, ok := <-conn.NotifyClose(make(chan *amqp.Error, 1)); ok {
+				failed <- fmt.Errorf("lost the connection to RabbitMQ: %w", err)
+			}
+		}()
+		a.Failed = failed
+		a.Queue, err = queue.NewRabbitMQAdapter(channel, cfg.RabbitMQWorkQueue, cfg.RabbitMQErrorQueue, cfg.WorkerConcurrency)
+		if err != nil {
+			a.Close()
+			return nil, fmt.Errorf("failed to initialize the RabbitMQ queues: %w", err)
+		}
+	case "nats":
+		client, err := nats.NewNATSClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to NATS JetStream: %w", err)
+		}
+		a.Close = client.Conn.Close
+		a.Queue = queue.NewJetStreamAdapter(client.Consumer, client.JetStream, cfg.NATSErrorSubject)
+	default:
+		a.Queue = queue.NewSQSAdapter(sqsClient, cfg.SQSErrorQueueURL, cfg.SQSWorkQueueURL, cfg.SQSVisibilityTimeout)
+	}
+	a.Readiness["queue"] = a.Queue.Ping
+
+	a.Events = events.NewNoopPublisher()
+	switch {
+	case cfg.SNSEventsTopicARN != "":
+		publisher := events.NewSNSPublisher(sns.NewFromConfig(awsCfg), cfg.SNSEventsTopicARN)
+		a.Events = publisher
+		a.Readiness["events"] = publisher.Ping
+	case cfg.SQSResultsQueueURL != "":
+		publisher := events.NewSQSPublisher(sqsClient, cfg.SQSResultsQueueURL)
+		a.Events = publisher
+		a.Readiness["events"] = publisher.Ping
+	default:
+		slog.Info("Neither SQS_RESULTS_QUEUE_URL nor SNS_EVENTS_TOPIC_ARN is set. Job events won't be published.")
+	}
+
+	return a, nil
+}
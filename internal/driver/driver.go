@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input/httpserver"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/config"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+)
+
+// The drivers the worker can run with.
+const (
+	// AWS keeps jobs in Postgres, files in S3 and messages in QUEUE_BACKEND.
+	AWS = "aws"
+	// Local keeps jobs and messages in memory and files on disk.
+	Local = "local"
+	// Memory keeps everything in memory.
+	Memory = "memory"
+)
+
+// Adapters are the adapters that keep the worker's jobs, files and messages,
+// as chosen by a driver.
+type Adapters struct {
+	Repository ports.VideoJobRepository
+	Outbox     ports.OutboxRepository
+	Storage    ports.S3Adapter
+	Queue      ports.QueueAdapter
+	Events     ports.EventPublisher
+	// Readiness checks the services the adapters depend on.
+	Readiness map[string]httpserver.Check
+	// Failed reports an adapter that stopped working for good, such as a
+	// dropped broker connection. It is nil when that can't happen.
+	Failed <-chan error
+	// Close releases the connections the adapters hold.
+	Close func()
+}
+
+// New builds the adapters of the named driver from config.Vars.
+func New(ctx context.Context, name string) (*Adapters, error) {
+	cfg := config.Vars
+
+	switch name {
+	case AWS:
+		return NewAWS(ctx)
+	case Local, Memory:
+		localCfg := LocalConfig{
+			UploadDir:         cfg.LocalUploadDir,
+			VisibilityTimeout: cfg.SQSVisibilityTimeout,
+			LinkExpiry:        cfg.S3PresignExpiry,
+		}
+		if name == Local {
+			localCfg.StorageDir = cfg.LocalStorageDir
+		}
+		local, err := NewLocal(ctx, localCfg)
+		if err != nil {
+			return nil, err
+		}
+		return &local.Adapters, nil
+	default:
+		return nil, fmt.Errorf("unknown driver '%s'", name)
+	}
+}
@@ -0,0 +1,133 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input/httpserver"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/events"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/queue"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/repository"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/ports"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+)
+
+// The user that owns the jobs enqueued by the local and memory drivers.
+const (
+	localUserID    = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	localUserEmail = "usuario@example.com"
+)
+
+// LocalConfig configures the local and memory drivers.
+type LocalConfig struct {
+	// StorageDir is where files are kept. Empty keeps them in memory.
+	StorageDir string
+	// UploadDir holds the files enqueued as jobs when the driver starts. A
+	// missing directory enqueues nothing.
+	UploadDir string
+	// VisibilityTimeout is how long a received message stays hidden.
+	VisibilityTimeout time.Duration
+	// LinkExpiry is how long the download links of archives are valid.
+	LinkExpiry time.Duration
+}
+
+// LocalAdapters keep jobs and messages in memory, and files either in memory or
+// under a directory, so the worker runs end to end without any container.
+// Jobs and Messages give tests access to what the adapters hold.
+type LocalAdapters struct {
+	Adapters
+	Jobs     *repository.MemoryVideoJobRepository
+	Messages *queue.MemoryQueue
+}
+
+// NewLocal builds the adapters of the local and memory drivers and enqueues
+// every file of the upload directory as a job.
+func NewLocal(ctx context.Context, cfg LocalConfig) (*LocalAdapters, error) {
+	var storageAdapter interface {
+		ports.S3Adapter
+		Ping(ctx context.Context) error
+	}
+	if cfg.StorageDir != "" {
+		if err := os.MkdirAll(cfg.StorageDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create the storage directory: %w", err)
+		}
+		storageAdapter = storage.NewLocalStorage(cfg.StorageDir, cfg.LinkExpiry)
+	} else {
+		storageAdapter = storage.NewMemoryStorage(cfg.LinkExpiry)
+	}
+
+	outbox := repository.NewMemoryOutboxRepository()
+	l := &LocalAdapters{
+		Jobs:     repository.NewMemoryVideoJobRepository(outbox),
+		Messages: queue.NewMemoryQueue(cfg.VisibilityTimeout),
+	}
+	l.Adapters = Adapters{
+		Repository: l.Jobs,
+		Outbox:     outbox,
+		Storage:    storageAdapter,
+		Queue:      l.Messages,
+		Events:     events.NewNoopPublisher(),
+		Readiness: map[string]httpserver.Check{
+			"storage": storageAdapter.Ping,
+		},
+		Close: func() {},
+	}
+
+	if err := l.enqueueUploads(ctx, cfg.UploadDir); err != nil {
+		return nil, fmt.Errorf("failed to enqueue the local uploads: %w", err)
+	}
+	slog.Info("Running without external services. Job events won't be published.")
+	return l, nil
+}
+
+// Enqueue stores the file at path under uploads/ and enqueues a job for it,
+// the way the API does for a user's upload. It returns the job ID.
+func (l *LocalAdapters) Enqueue(ctx context.Context, path string) (string, error) {
+	videoPath := "uploads/" + filepath.Base(path)
+	if err := l.Storage.UploadFile(ctx, path, videoPath); err != nil {
+		return "", err
+	}
+	job := l.Jobs.AddJob(domain.VideoJob{UserID: localUserID, VideoPath: videoPath}, localUserEmail)
+	body, err := json.Marshal(domain.JobMessageEvent{JobID: job.ID})
+	if err != nil {
+		return "", err
+	}
+	l.Messages.Send(body, nil)
+	slog.Info("Enqueued local job.", logger.JobIDKey, job.ID, "video_path", videoPath)
+	return job.ID, nil
+}
+
+// enqueueUploads enqueues every regular, non-hidden file of dir.
+func (l *LocalAdapters) enqueueUploads(ctx context.Context, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.Info("Upload directory not found. No job was enqueued.", "dir", dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, err := l.Enqueue(ctx, filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
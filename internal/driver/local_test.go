@@ -0,0 +1,122 @@
+package driver_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/input"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/metrics"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/notification"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/processor"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/storage"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/adapters/output/workspace"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/domain"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/core/service"
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/driver"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocal(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should enqueue every file of the upload directory", func(t *testing.T) {
+		uploadDir := t.TempDir()
+		storageDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(uploadDir, "video.mp4"), []byte("video"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(uploadDir, ".gitkeep"), nil, 0o644))
+
+		local, err := driver.NewLocal(ctx, driver.LocalConfig{UploadDir: uploadDir, StorageDir: storageDir, VisibilityTimeout: time.Minute})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, local.Messages.Len())
+		assert.FileExists(t, filepath.Join(storageDir, "uploads", "video.mp4"))
+		assert.NoError(t, local.Readiness["storage"](ctx))
+	})
+
+	t.Run("should enqueue nothing when the upload directory does not exist", func(t *testing.T) {
+		local, err := driver.NewLocal(ctx, driver.LocalConfig{UploadDir: filepath.Join(t.TempDir(), "missing"), VisibilityTimeout: time.Minute})
+		require.NoError(t, err)
+
+		assert.Zero(t, local.Messages.Len())
+	})
+}
+
+// TestLocalAdapters_EndToEnd runs jobs through the consumer and the job
+// service on the memory driver, with the real ffmpeg processor.
+func TestLocalAdapters_EndToEnd(t *testing.T) {
+	for _, tool := range []string{"ffmpeg", "ffprobe"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	videoPath := filepath.Join(dir, "video.mp4")
+	cmd := exec.Command("ffmpeg", "-f", "lavfi", "-i", "color=c=black:s=320x240:d=2", videoPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to create dummy video: %v, output: %s", err, output)
+	}
+	notVideoPath := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notVideoPath, []byte("not a video"), 0o644))
+
+	local, err := driver.NewLocal(ctx, driver.LocalConfig{VisibilityTimeout: time.Minute, LinkExpiry: time.Hour})
+	require.NoError(t, err)
+	completedID, err := local.Enqueue(ctx, videoPath)
+	require.NoError(t, err)
+	failedID, err := local.Enqueue(ctx, notVideoPath)
+	require.NoError(t, err)
+
+	recorder := metrics.NewPrometheusRecorder(prometheus.NewRegistry())
+	jobService := service.NewJobService(
+		local.Repository,
+		local.Storage,
+		processor.NewFFmpegProcessor(),
+		local.Events,
+		notification.NewNoopNotifier(),
+		workspace.NewManager(t.TempDir(), "worker-1"),
+		recorder,
+		service.Config{MaxAttempts: 1, WorkerID: "worker-1", LeaseDuration: time.Minute},
+	)
+	consumer := input.NewConsumer(local.Queue, jobService, recorder, input.ConsumerConfig{
+		Concurrency:       2,
+		VisibilityTimeout: time.Minute,
+		RetryDelay:        time.Second,
+	})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		consumer.Start(ctx)
+	}()
+
+	finished := func(jobID string) bool {
+		job, _ := local.Jobs.Job(jobID)
+		return job.Status == domain.VideoStatusCompleted || job.Status == domain.VideoStatusFailed
+	}
+	require.Eventually(t, func() bool {
+		return finished(completedID) && finished(failedID)
+	}, 30*time.Second, 50*time.Millisecond)
+	cancel()
+	<-stopped
+	require.NoError(t, consumer.Shutdown(context.Background()))
+
+	completed, _ := local.Jobs.Job(completedID)
+	assert.Equal(t, domain.VideoStatusCompleted, completed.Status)
+	require.NotNil(t, completed.OutputPath)
+	archive, ok := local.Storage.(*storage.MemoryStorage).Get(*completed.OutputPath)
+	assert.True(t, ok)
+	assert.NotEmpty(t, archive)
+
+	failed, _ := local.Jobs.Job(failedID)
+	assert.Equal(t, domain.VideoStatusFailed, failed.Status)
+	require.NotNil(t, failed.FailureCode)
+	assert.Equal(t, domain.FailureInvalidVideo, *failed.FailureCode)
+	assert.Eventually(t, func() bool { return local.Messages.Len() == 0 }, time.Second, 10*time.Millisecond, "both messages must be acked")
+}
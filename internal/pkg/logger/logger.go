@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Attribute keys shared by every log line about a job.
+const (
+	WorkerIDKey     = "worker_id"
+	MessageIDKey    = "message_id"
+	ReceiveCountKey = "receive_count"
+	JobIDKey        = "job_id"
+	UserIDKey       = "user_id"
+)
+
+type contextKey struct{}
+
+// New returns a logger that writes JSON lines to w, dropping records below
+// level.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// WithContext returns a copy of ctx carrying l.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger when
+// there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// With adds attributes to the logger carried by ctx, so that everything
+// logged further down the call chain includes them.
+func With(ctx context.Context, args ...any) (context.Context, *slog.Logger) {
+	l := FromContext(ctx).With(args...)
+	return WithContext(ctx, l), l
+}
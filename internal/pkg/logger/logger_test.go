@@ -0,0 +1,52 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/fiap-challenger-soat/hackthon-soat-process-worker/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWith(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	ctx := logger.WithContext(context.Background(), logger.New(&buf, slog.LevelInfo).With(logger.WorkerIDKey, "worker-1"))
+
+	ctx, _ = logger.With(ctx, logger.JobIDKey, "job-123")
+	_, _ = logger.With(ctx, logger.UserIDKey, "user-123")
+	logger.FromContext(ctx).Info("processing started", "attempt", 2)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "processing started", line["msg"])
+	assert.Equal(t, "worker-1", line[logger.WorkerIDKey])
+	assert.Equal(t, "job-123", line[logger.JobIDKey])
+	assert.EqualValues(t, 2, line["attempt"])
+	assert.NotContains(t, line, logger.UserIDKey, "attributes added to a child context must not leak to the parent")
+}
+
+func TestNew_DropsRecordsBelowLevel(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := logger.New(&buf, slog.LevelWarn)
+
+	l.Info("ignored")
+	l.Warn("kept")
+
+	assert.NotContains(t, buf.String(), "ignored")
+	assert.Contains(t, buf.String(), "kept")
+}
+
+func TestFromContext_FallsBackToDefault(t *testing.T) {
+	t.Parallel()
+
+	assert.Same(t, slog.Default(), logger.FromContext(context.Background()))
+}
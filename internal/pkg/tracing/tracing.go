@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// End marks the span as failed when err is not nil and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SQSCarrier reads and writes the trace context as SQS message attributes.
+type SQSCarrier map[string]types.MessageAttributeValue
+
+var _ propagation.TextMapCarrier = SQSCarrier{}
+
+func (c SQSCarrier) Get(key string) string {
+	attr, ok := c[key]
+	if !ok || aws.ToString(attr.DataType) != "String" {
+		return ""
+	}
+	return aws.ToString(attr.StringValue)
+}
+
+func (c SQSCarrier) Set(key, value string) {
+	c[key] = types.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+func (c SQSCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// SNSCarrier writes the trace context as SNS message attributes, which SQS
+// subscribers receive as message attributes too.
+type SNSCarrier map[string]snstypes.MessageAttributeValue
+
+var _ propagation.TextMapCarrier = SNSCarrier{}
+
+func (c SNSCarrier) Get(key string) string {
+	attr, ok := c[key]
+	if !ok || aws.ToString(attr.DataType) != "String" {
+		return ""
+	}
+	return aws.ToString(attr.StringValue)
+}
+
+func (c SNSCarrier) Set(key, value string) {
+	c[key] = snstypes.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+func (c SNSCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}